@@ -41,13 +41,25 @@ func main() {
 		nexa.Shutdown()
 	}()
 
+	if cfg.Daemon() {
+		interval := cfg.RunInterval()
+		log.Printf("Running checks every %v", interval)
+		nexa.Serve(interval, func(result *checker.GlobalResult) {
+			printResult(cfg, result)
+		})
+		os.Exit(0)
+	}
+
 	result := nexa.Run()
+	printResult(cfg, result)
 
+	os.Exit(result.ExitCode())
+}
+
+func printResult(cfg *config.Config, result *checker.GlobalResult) {
 	if cfg.StdoutJSON {
 		result.PrintJSON()
 	} else {
 		result.PrintHuman()
 	}
-
-	os.Exit(result.ExitCode())
 }
\ No newline at end of file
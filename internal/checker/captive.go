@@ -0,0 +1,269 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("captive_portal", newCaptivePortalProbe)
+}
+
+// DefaultPortalStatus is the status a detection URL must return when no
+// captive portal intercepts the request.
+const DefaultPortalStatus = http.StatusNoContent
+
+// maxPortalBody bounds how much of a response body is inspected.
+const maxPortalBody = 64 << 10
+
+// Portal verdicts for a single detection URL.
+const (
+	portalClear       = "clear"
+	portalRedirect    = "redirect"
+	portalSubstituted = "substituted"
+	portalError       = "error"
+)
+
+var (
+	metaRefreshURL = regexp.MustCompile(`(?is)<meta[^>]+http-equiv=["']?refresh["']?[^>]*content=["']?\s*\d*\s*;\s*url=['"]?([^"'>\s]+)`)
+	scriptLocation = regexp.MustCompile(`(?i)(?:window\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']`)
+)
+
+// PortalCheck is the outcome of one detection URL.
+type PortalCheck struct {
+	URL       string  `json:"url"`
+	Verdict   string  `json:"verdict"`
+	Status    int     `json:"status,omitempty"`
+	PortalURL string  `json:"portal_url,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	err       error
+}
+
+// portal reports whether the check saw an intercepting portal.
+func (c PortalCheck) portal() bool {
+	return c.Verdict == portalRedirect || c.Verdict == portalSubstituted
+}
+
+type captivePortalProbe struct {
+	urls      []config.CaptiveURL
+	agreement string
+	timeout   time.Duration
+}
+
+func newCaptivePortalProbe(cfg *config.Config) Probe {
+	return &captivePortalProbe{
+		urls:      captiveURLs(cfg),
+		agreement: cfg.CaptivePortal.Agreement,
+		timeout:   cfg.HTTPTimeout,
+	}
+}
+
+func (p *captivePortalProbe) Name() string { return "captive_portal" }
+
+// Execute queries every detection URL and combines the verdicts according to
+// the agreement mode. Failed requests are inconclusive and do not count as
+// portal evidence.
+func (p *captivePortalProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	urls := p.urls
+	if target.HTTPURL != "" {
+		urls = []config.CaptiveURL{{URL: target.HTTPURL}}
+	}
+	if len(urls) == 0 {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, fmt.Errorf("no captive portal detection URL configured")))
+	}
+
+	timeout := target.timeout(p.timeout)
+	checks := make([]PortalCheck, 0, len(urls))
+	var latency time.Duration
+	for _, u := range urls {
+		check, elapsed := CheckCaptivePortal(ctx, u, timeout)
+		checks = append(checks, check)
+		latency += elapsed
+	}
+
+	detected, portalURL, err := portalAgreement(p.agreement, checks)
+	result := newProbeResult(latency, err)
+	result.Details = map[string]interface{}{
+		"captive_portal": detected,
+		"agreement":      agreementMode(p.agreement),
+		"checks":         checks,
+	}
+	if portalURL != "" {
+		result.Details["portal_url"] = portalURL
+	}
+	return result
+}
+
+// CheckCaptivePortal requests u without following redirects and compares the
+// response to the expected one. A redirect or a different status or body
+// means a portal intercepted the request.
+func CheckCaptivePortal(ctx context.Context, u config.CaptiveURL, timeout time.Duration) (PortalCheck, time.Duration) {
+	check := PortalCheck{URL: u.URL}
+
+	req, err := newHTTPRequest(ctx, "GET", u.URL, nil)
+	if err != nil {
+		return check.failed(newProbeError(ErrorClassConfig, err)), 0
+	}
+
+	resp, ttfb, err := sendHTTP(newHTTPClient(timeout, 0), req)
+	check.LatencyMs = durationMs(ttfb)
+	if err != nil {
+		return check.failed(err), ttfb
+	}
+	defer resp.Body.Close()
+
+	check.Status = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxPortalBody))
+
+	expect := u.ExpectStatus
+	if expect == 0 {
+		expect = DefaultPortalStatus
+	}
+
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400 && expect != resp.StatusCode:
+		check.Verdict = portalRedirect
+		check.PortalURL = resolveReference(req.URL, resp.Header.Get("Location"))
+	case resp.StatusCode != expect, u.ExpectBody != "" && !strings.Contains(string(body), u.ExpectBody):
+		check.Verdict = portalSubstituted
+		check.PortalURL = bodyPortalURL(req.URL, body)
+	default:
+		check.Verdict = portalClear
+	}
+	return check, ttfb
+}
+
+func (c PortalCheck) failed(err error) PortalCheck {
+	c.Verdict = portalError
+	c.Error = err.Error()
+	c.err = err
+	return c
+}
+
+func agreementMode(mode string) string {
+	if mode == "" {
+		return "all"
+	}
+	return mode
+}
+
+// portalAgreement decides whether the network is free of a captive portal.
+// With "all" every URL must come back clear, with "majority" more than half
+// must, and with "any" a single clear URL is enough. The portal URL of the
+// first intercepted check is reported.
+func portalAgreement(mode string, checks []PortalCheck) (bool, string, error) {
+	var clear, portals int
+	var portalURL string
+	var firstErr error
+	for _, check := range checks {
+		switch {
+		case check.Verdict == portalClear:
+			clear++
+		case check.portal():
+			portals++
+			if portalURL == "" {
+				portalURL = check.PortalURL
+			}
+		case firstErr == nil:
+			firstErr = check.err
+		}
+	}
+
+	var ok bool
+	switch agreementMode(mode) {
+	case "any":
+		ok = clear > 0
+	case "majority":
+		ok = clear*2 > len(checks)
+	default:
+		ok = clear == len(checks)
+	}
+	if ok {
+		return false, "", nil
+	}
+
+	if portals > 0 {
+		err := fmt.Errorf("captive portal detected by %d of %d URLs", portals, len(checks))
+		if portalURL != "" {
+			err = fmt.Errorf("captive portal detected at %s", portalURL)
+		}
+		return true, portalURL, newProbeError(ErrorClassCaptivePortal, err)
+	}
+	return false, "", firstErr
+}
+
+func validateCaptivePortal(cfg *config.Config) error {
+	switch cfg.CaptivePortal.Agreement {
+	case "", "all", "majority", "any":
+	default:
+		return fmt.Errorf("invalid captive_portal agreement %q: expected all, majority or any", cfg.CaptivePortal.Agreement)
+	}
+	for _, u := range cfg.CaptivePortal.URLs {
+		if u.URL == "" {
+			return fmt.Errorf("captive_portal url must not be empty")
+		}
+	}
+	return nil
+}
+
+// captiveURLs returns the detection URLs, falling back to http_url.
+func captiveURLs(cfg *config.Config) []config.CaptiveURL {
+	if len(cfg.CaptivePortal.URLs) > 0 {
+		return cfg.CaptivePortal.URLs
+	}
+	if cfg.HTTPURL != "" {
+		return []config.CaptiveURL{{URL: cfg.HTTPURL}}
+	}
+	return nil
+}
+
+// bodyPortalURL extracts a login page URL from a substituted body, looking at
+// meta refresh tags and script redirects.
+func bodyPortalURL(base *url.URL, body []byte) string {
+	for _, re := range []*regexp.Regexp{metaRefreshURL, scriptLocation} {
+		if m := re.FindSubmatch(body); m != nil {
+			return resolveReference(base, string(m[1]))
+		}
+	}
+	return ""
+}
+
+func resolveReference(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// captivePortalStatus reports the portal verdict of the run-level
+// captive_portal check, if it ran.
+func captivePortalStatus(global map[string]CheckResult) (bool, string) {
+	check, ok := global["captive_portal"]
+	if !ok {
+		return false, ""
+	}
+	details := check.Probes["captive_portal"].Details
+	detected, _ := details["captive_portal"].(bool)
+	portalURL, _ := details["portal_url"].(string)
+	return detected, portalURL
+}
+
+func portalLabel(portalURL string) string {
+	if portalURL == "" {
+		return "detected"
+	}
+	return portalURL
+}
\ No newline at end of file
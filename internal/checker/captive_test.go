@@ -0,0 +1,152 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func newPortalServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/generate_204", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login?continue=1", http.StatusFound)
+	})
+	mux.HandleFunc("/substituted", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><meta http-equiv="refresh" content="0; url=https://portal.example/login"></head></html>`)
+	})
+	mux.HandleFunc("/success.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "success\n")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckCaptivePortal(t *testing.T) {
+	server := newPortalServer(t)
+
+	tests := []struct {
+		name      string
+		url       config.CaptiveURL
+		verdict   string
+		portalURL string
+	}{
+		{"no content", config.CaptiveURL{URL: server.URL + "/generate_204"}, portalClear, ""},
+		{"redirect", config.CaptiveURL{URL: server.URL + "/redirect"}, portalRedirect, server.URL + "/login?continue=1"},
+		{"substituted", config.CaptiveURL{URL: server.URL + "/substituted"}, portalSubstituted, "https://portal.example/login"},
+		{"expected body", config.CaptiveURL{URL: server.URL + "/success.txt", ExpectStatus: 200, ExpectBody: "success"}, portalClear, ""},
+		{"unexpected body", config.CaptiveURL{URL: server.URL + "/substituted", ExpectStatus: 200, ExpectBody: "success"}, portalSubstituted, "https://portal.example/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, _ := CheckCaptivePortal(context.Background(), tt.url, 2*time.Second)
+			if check.Verdict != tt.verdict || check.PortalURL != tt.portalURL {
+				t.Errorf("Expected %s with portal %q, got %s with portal %q", tt.verdict, tt.portalURL, check.Verdict, check.PortalURL)
+			}
+		})
+	}
+}
+
+func TestPortalAgreement(t *testing.T) {
+	clear := PortalCheck{Verdict: portalClear}
+	portal := PortalCheck{Verdict: portalRedirect, PortalURL: "http://portal.example/"}
+	failed := PortalCheck{Verdict: portalError, err: newProbeError(ErrorClassConnRefused, errors.New("refused"))}
+
+	tests := []struct {
+		mode     string
+		checks   []PortalCheck
+		detected bool
+		class    ErrorClass
+	}{
+		{"all", []PortalCheck{clear, clear}, false, ""},
+		{"all", []PortalCheck{clear, portal}, true, ErrorClassCaptivePortal},
+		{"majority", []PortalCheck{clear, clear, portal}, false, ""},
+		{"majority", []PortalCheck{clear, portal}, true, ErrorClassCaptivePortal},
+		{"any", []PortalCheck{portal, clear}, false, ""},
+		{"all", []PortalCheck{clear, failed}, false, ErrorClassConnRefused},
+	}
+
+	for _, tt := range tests {
+		detected, _, err := portalAgreement(tt.mode, tt.checks)
+		class := ClassifyError(err)
+		if detected != tt.detected || class != tt.class {
+			t.Errorf("%s %+v: expected detected=%v class=%q, got %v %q", tt.mode, tt.checks, tt.detected, tt.class, detected, class)
+		}
+	}
+}
+
+func TestNexaCaptivePortal(t *testing.T) {
+	server := newPortalServer(t)
+
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{{Host: "8.8.8.8", Probes: []string{"mock-down"}}},
+		HTTPURL:       server.URL + "/redirect",
+		HTTPTimeout:   2 * time.Second,
+		CaptivePortal: config.CaptivePortal{Enabled: true},
+		Attempts:      1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	if _, ok := result.Global["http"]; ok {
+		t.Errorf("Expected captive_portal to replace the global http check")
+	}
+	if !result.CaptivePortal || result.PortalURL != server.URL+"/login?continue=1" {
+		t.Errorf("Expected portal at %s/login?continue=1, got %v %q", server.URL, result.CaptivePortal, result.PortalURL)
+	}
+	if result.InternetOK {
+		t.Errorf("Expected internet to be down behind a captive portal")
+	}
+	if class := result.Global["captive_portal"].ErrorClass; class != ErrorClassCaptivePortal {
+		t.Errorf("Expected error class %s, got %s", ErrorClassCaptivePortal, class)
+	}
+}
+
+// TestNexaBaselineHTTPURL checks that an http_url answering 200 or a
+// redirect keeps internet up when captive portal detection is off.
+func TestNexaBaselineHTTPURL(t *testing.T) {
+	server := newPortalServer(t)
+
+	for _, path := range []string{"/success.txt", "/redirect"} {
+		cfg := &config.Config{
+			HTTPURL:     server.URL + path,
+			HTTPTimeout: 2 * time.Second,
+			Attempts:    1,
+		}
+		checker, err := NewNexa(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create Nexa: %v", err)
+		}
+
+		result := checker.Run()
+		if _, ok := result.Global["http"]; !ok || result.CaptivePortal {
+			t.Errorf("%s: expected the plain http check, got %v", path, result.Global)
+		}
+		if !result.InternetOK {
+			t.Errorf("%s: expected internet to be up", path)
+		}
+	}
+}
+
+func TestNexaInvalidCaptiveAgreement(t *testing.T) {
+	cfg := &config.Config{
+		CaptivePortal: config.CaptivePortal{Enabled: true, Agreement: "most"},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an invalid agreement mode")
+	}
+}
\ No newline at end of file
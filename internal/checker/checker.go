@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,35 +24,124 @@ const (
 )
 
 type CheckResult struct {
-	Type      CheckType               `json:"type"`
-	Host      string                  `json:"host"`
-	Port      int                     `json:"port,omitempty"`
-	Success   bool                    `json:"success"`
-	Error     string                  `json:"error,omitempty"`
-	Details   map[string]interface{}  `json:"details"`
-	Duration  time.Duration           `json:"duration_ms"`
-	Timestamp time.Time               `json:"timestamp"`
+	Type       CheckType              `json:"type"`
+	Host       string                 `json:"host"`
+	Port       int                    `json:"port,omitempty"`
+	Success    bool                   `json:"success"`
+	Degraded   bool                   `json:"degraded,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	ErrorClass ErrorClass             `json:"error_class,omitempty"`
+	Weight     int                    `json:"weight,omitempty"`
+	Details    map[string]interface{} `json:"details"`
+	Probes     map[string]ProbeResult `json:"probes,omitempty"`
+	Duration   time.Duration          `json:"duration_ms"`
+	Timestamp  time.Time              `json:"timestamp"`
 }
 
 type GlobalResult struct {
-	InternetOK       bool                   `json:"internet"`
-	CorporateOK      bool                   `json:"corporate"`
-	Timestamp        time.Time              `json:"timestamp"`
-	ElapsedSeconds   float64                `json:"elapsed_s"`
-	InternetDetails  map[string]CheckResult `json:"internet_details"`
-	CorporateDetails map[string]CheckResult `json:"corporate_details"`
-	Summary          types.SummaryStats     `json:"summary"`
+	InternetOK       bool                    `json:"internet"`
+	CorporateOK      bool                    `json:"corporate"`
+	CaptivePortal    bool                    `json:"captive_portal"`
+	PortalURL        string                  `json:"portal_url,omitempty"`
+	TLSIntercepted   bool                    `json:"tls_intercepted"`
+	ObservedIssuer   string                  `json:"observed_issuer,omitempty"`
+	DNSView          string                  `json:"dns_view,omitempty"`
+	DNSHijacked      bool                    `json:"dns_hijacked"`
+	HijackResolvers  []string                `json:"hijack_resolvers,omitempty"`
+	FakeAnswers      []string                `json:"fake_answers,omitempty"`
+	Timestamp        time.Time               `json:"timestamp"`
+	ElapsedSeconds   float64                 `json:"elapsed_s"`
+	InternetDetails  map[string]CheckResult  `json:"internet_details"`
+	CorporateDetails map[string]CheckResult  `json:"corporate_details"`
+	Global           map[string]CheckResult  `json:"global,omitempty"`
+	Groups           map[string]*GroupResult `json:"groups,omitempty"`
+	Summary          types.SummaryStats      `json:"summary"`
 }
 
 type Nexa struct {
-	config  *config.Config
-	metrics *metrics.PrometheusMetrics
-	logger  *log.Logger
-	ctx     context.Context
-	cancel  context.CancelFunc
+	config    *config.Config
+	probes    map[string]Probe
+	policies  map[string]Policy
+	scheduler *scheduler
+	metrics   *metrics.PrometheusMetrics
+	logger    *log.Logger
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu   sync.RWMutex
+	last *GlobalResult
 }
 
 func NewNexa(cfg *config.Config) (*Nexa, error) {
+	if err := validateGroups(cfg); err != nil {
+		return nil, err
+	}
+
+	policies, err := groupPolicies(configGroups(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	probes := newProbes(cfg)
+	for _, target := range configTargets(cfg) {
+		for _, name := range target.Probes {
+			if _, ok := probes[name]; !ok {
+				return nil, fmt.Errorf("unknown probe %q for host %s", name, target.Host)
+			}
+		}
+		if target.Weight < 0 {
+			return nil, fmt.Errorf("host %s: weight %d must be positive", target.Host, target.Weight)
+		}
+		if err := validatePins(target); err != nil {
+			return nil, err
+		}
+		if err := validateHTTPOptions(target.Host, target.HTTP); err != nil {
+			return nil, err
+		}
+		if err := validateScenario(target); err != nil {
+			return nil, err
+		}
+		if err := validateDNSQuery(targetDNSQuery(cfg.DNSProbe, target)); err != nil {
+			return nil, fmt.Errorf("host %s: %v", target.Host, err)
+		}
+		if err := validatePingOptions(targetPingOptions(cfg.Ping, target)); err != nil {
+			return nil, fmt.Errorf("host %s: %v", target.Host, err)
+		}
+		if err := validateTCPOptions(target.TCP); err != nil {
+			return nil, fmt.Errorf("host %s: tcp: %v", target.Host, err)
+		}
+		if err := validateHostKeyFingerprints(target.HostKeyFingerprints); err != nil {
+			return nil, fmt.Errorf("host %s: %v", target.Host, err)
+		}
+		if err := validateExchange(target.UDP); err != nil {
+			return nil, fmt.Errorf("host %s: udp: %v", target.Host, err)
+		}
+		if err := validateNTPOptions(targetNTPOptions(cfg.NTP, target)); err != nil {
+			return nil, fmt.Errorf("host %s: %v", target.Host, err)
+		}
+	}
+	if err := validateDNSQuery(cfg.DNSProbe); err != nil {
+		return nil, fmt.Errorf("dns_probe: %v", err)
+	}
+	if err := validatePingOptions(cfg.Ping); err != nil {
+		return nil, err
+	}
+	if err := validateNTPOptions(cfg.NTP); err != nil {
+		return nil, err
+	}
+	if err := validateGlobalChecks(cfg, probes); err != nil {
+		return nil, err
+	}
+	if err := validateCaptivePortal(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateSplitHorizon(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateDNSIntegrity(cfg); err != nil {
+		return nil, err
+	}
+
 	var promMetrics *metrics.PrometheusMetrics
 	if cfg.Prometheus {
 		var err error
@@ -60,14 +151,22 @@ func NewNexa(cfg *config.Config) (*Nexa, error) {
 		}
 	}
 
+	sched := newScheduler(cfg, promMetrics)
+	if ping, ok := probes["ping"].(*pingProbe); ok {
+		ping.acquire = sched.acquireProbe
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Nexa{
-		config:  cfg,
-		metrics: promMetrics,
-		logger:  log.New(log.Writer(), "[nexa] ", log.LstdFlags),
-		ctx:     ctx,
-		cancel:  cancel,
+		config:    cfg,
+		probes:    probes,
+		policies:  policies,
+		scheduler: sched,
+		metrics:   promMetrics,
+		logger:    log.New(log.Writer(), "[nexa] ", log.LstdFlags),
+		ctx:       ctx,
+		cancel:    cancel,
 	}, nil
 }
 
@@ -75,16 +174,59 @@ func (nc *Nexa) Run() *GlobalResult {
 	return nc.RunWithContext(nc.ctx)
 }
 
+// Serve runs the checks every interval until Shutdown is called. Each
+// completed result is passed to onResult, which may be nil.
+func (nc *Nexa) Serve(interval time.Duration, onResult func(*GlobalResult)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result := nc.Run()
+		if nc.ctx.Err() != nil {
+			return
+		}
+		if onResult != nil {
+			onResult(result)
+		}
+
+		select {
+		case <-nc.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastResult returns the most recent completed result, or nil if no run
+// has finished yet.
+func (nc *Nexa) LastResult() *GlobalResult {
+	nc.mu.RLock()
+	defer nc.mu.RUnlock()
+	return nc.last
+}
+
 func (nc *Nexa) RunWithContext(ctx context.Context) *GlobalResult {
 	startTime := time.Now()
 	result := &GlobalResult{
-		Timestamp:        startTime,
-		InternetDetails:  make(map[string]CheckResult),
-		CorporateDetails: make(map[string]CheckResult),
+		Timestamp: startTime,
+		Global:    make(map[string]CheckResult),
+		Groups:    make(map[string]*GroupResult),
+	}
+
+	groups := configGroups(nc.config)
+	for _, group := range groups {
+		result.Groups[group.Name] = &GroupResult{
+			ExitCode:     group.ExitCode,
+			GlobalChecks: groupGlobalChecks(nc.config, group),
+			Details:      make(map[string]CheckResult),
+		}
 	}
+	result.InternetDetails = result.Groups[string(CheckTypeExternal)].Details
+	result.CorporateDetails = result.Groups[string(CheckTypeCorporate)].Details
+
+	targets := append(globalTargets(nc.config), configTargets(nc.config)...)
 
-	var wg sync.WaitGroup
-	results := make(chan CheckResult, len(nc.config.ExternalHosts)+len(nc.config.CorpHosts))
+	results := make(chan CheckResult, len(targets))
 
 	// Check for context cancellation
 	if ctx.Err() != nil {
@@ -92,58 +234,55 @@ func (nc *Nexa) RunWithContext(ctx context.Context) *GlobalResult {
 		return result
 	}
 
-	for _, hp := range nc.config.ExternalHosts {
-		wg.Add(1)
-		go func(hp config.HostPort) {
-			defer wg.Done()
-			select {
-			case <-ctx.Done():
-				nc.logger.Printf("Check cancelled for %s:%d", hp.Host, hp.Port)
-				return
-			default:
-				checkResult := nc.checkExternal(ctx, hp)
-				results <- checkResult
-			}
-		}(hp)
-	}
-
-	for _, hp := range nc.config.CorpHosts {
-		wg.Add(1)
-		go func(hp config.HostPort) {
-			defer wg.Done()
-			select {
-			case <-ctx.Done():
-				nc.logger.Printf("Check cancelled for %s:%d", hp.Host, hp.Port)
-				return
-			default:
-				checkResult := nc.checkCorporate(ctx, hp)
-				results <- checkResult
-			}
-		}(hp)
-	}
-
-	wg.Wait()
+	nc.scheduler.run(ctx, targets, func(target Target) {
+		results <- nc.checkTarget(ctx, target)
+	}, func(target Target) {
+		nc.logger.Printf("Check cancelled for %s:%d", target.Host, target.Port)
+	})
 	close(results)
 
 	for checkResult := range results {
-		key := fmt.Sprintf("%s:%s:%d", checkResult.Type, checkResult.Host, checkResult.Port)
-		if checkResult.Type == CheckTypeExternal {
-			result.InternetDetails[key] = checkResult
-		} else {
-			result.CorporateDetails[key] = checkResult
+		if checkResult.Type == CheckTypeGlobal {
+			for name := range checkResult.Probes {
+				result.Global[name] = checkResult
+			}
+			continue
 		}
+		key := fmt.Sprintf("%s:%s:%d", checkResult.Type, checkResult.Host, checkResult.Port)
+		result.Groups[string(checkResult.Type)].Details[key] = checkResult
 	}
 
-	result.InternetOK = nc.determineInternetStatus(result)
-	result.CorporateOK = nc.determineCorporateStatus(result)
+	for name, group := range result.Groups {
+		policy := nc.policies[name]
+		group.Policy = policy.String()
+		group.OK, group.PassedWeight, group.TotalWeight = policy.Evaluate(policyInputs(group, result.Global))
+	}
+	result.InternetOK = result.Groups[string(CheckTypeExternal)].OK
+	result.CorporateOK = result.Groups[string(CheckTypeCorporate)].OK
+	result.CaptivePortal, result.PortalURL = captivePortalStatus(result.Global)
+	result.TLSIntercepted, result.ObservedIssuer = tlsInterception(result)
+	result.DNSView = splitHorizonView(result.Global)
+	result.DNSHijacked, result.HijackResolvers, result.FakeAnswers = dnsHijack(result.Global)
 	result.ElapsedSeconds = time.Since(startTime).Seconds()
 	result.Summary = nc.calculateSummary(result)
 
 	if nc.metrics != nil {
 	    nc.metrics.UpdateInternetStatus(result.InternetOK)
 	    nc.metrics.UpdateCorporateStatus(result.CorporateOK) 
+		nc.metrics.UpdateCaptivePortal(result.CaptivePortal)
+		nc.metrics.UpdateTLSIntercepted(result.TLSIntercepted)
+		nc.metrics.UpdateDNSHijacked(result.DNSHijacked)
 	    nc.metrics.UpdateCheckDuration(result.ElapsedSeconds)
 	    nc.metrics.UpdateCheckSummary(result.Summary)
+		for name, group := range result.Groups {
+			nc.metrics.UpdateGroupStatus(name, group.OK)
+		}
+	}
+
+	if ctx.Err() == nil {
+		nc.mu.Lock()
+		nc.last = result
+		nc.mu.Unlock()
 	}
 
 	return result
@@ -154,140 +293,138 @@ func (nc *Nexa) Shutdown() {
 	nc.cancel()
 }
 
-func (nc *Nexa) checkExternal(ctx context.Context, hp config.HostPort) CheckResult {
+func (nc *Nexa) checkTarget(ctx context.Context, target Target) CheckResult {
 	startTime := time.Now()
 	result := CheckResult{
-		Type:      CheckTypeExternal,
-		Host:      hp.Host,
-		Port:      hp.Port,
+		Type:      target.Type,
+		Host:      target.Host,
+		Port:      target.Port,
+		Weight:    target.Weight,
 		Details:   make(map[string]interface{}),
+		Probes:    make(map[string]ProbeResult),
 		Timestamp: startTime,
 	}
 
-	if hp.Port > 0 {
-		tcpOK := utils.Retry(nc.config.Attempts, nc.config.Backoff, func() bool {
+	attempts := nc.config.Attempts
+	if target.Attempts > 0 {
+		attempts = target.Attempts
+	}
+
+	label := metricTarget(target)
+	for _, name := range targetProbes(target) {
+		probe := nc.probes[name]
+
+		var probeResult ProbeResult
+		var tries int
+		utils.Retry(ctx, attempts, nc.config.Backoff, func() bool {
 			if ctx.Err() != nil {
 				return false
 			}
-			return CheckTCP(hp.Host, hp.Port, nc.config.TCPTimeout)
+			release, ok := nc.scheduler.acquireProbe(ctx, name)
+			if !ok {
+				return false
+			}
+			defer release()
+
+			tries++
+			start := time.Now()
+			probeResult = probe.Execute(ctx, target)
+			if probeResult.LatencyMs == 0 {
+				probeResult.LatencyMs = durationMs(time.Since(start))
+			}
+			return probeResult.Success
 		})
-		result.Details["tcp"] = tcpOK
-	}
+		probeResult.Probe = name
+		probeResult.Attempts = tries
 
-	pingOK := utils.Retry(nc.config.Attempts, nc.config.Backoff, func() bool {
-		if ctx.Err() != nil {
-			return false
+		result.Details[name] = probeResult.Success
+		result.Probes[name] = probeResult
+		for key, value := range probeResult.checkDetails {
+			result.Details[key] = value
+		}
+		if probeResult.Success {
+			result.Success = true
+			result.Degraded = result.Degraded || probeResult.Degraded
+		} else if result.Error == "" {
+			result.Error = probeResult.Error
+			result.ErrorClass = probeResult.ErrorClass
 		}
-		return CheckPing(hp.Host, nc.config.PingTimeout, nc.config.Attempts)
-	})
-	result.Details["ping"] = pingOK
 
-	if nc.config.HTTPURL != "" {
-		httpOK := utils.Retry(nc.config.Attempts, nc.config.Backoff, func() bool {
-			if ctx.Err() != nil {
-				return false
+		if nc.metrics != nil {
+			if probeResult.Success {
+				nc.metrics.ObserveProbeLatency(label, name, probeResult.LatencyMs/1000)
+			} else {
+				nc.metrics.RecordProbeFailure(string(target.Type), name, string(probeResult.ErrorClass))
 			}
-			return CheckHTTP(nc.config.HTTPURL, nc.config.HTTPTimeout)
-		})
-		result.Details["http"] = httpOK
-		result.Details["http_url"] = nc.config.HTTPURL
+			if probeResult.record != nil {
+				probeResult.record(nc.metrics, label)
+			}
+		}
 	}
 
-	tcpOK, hasTCP := result.Details["tcp"].(bool)
-	pingOK, hasPing := result.Details["ping"].(bool)
-	httpOK, hasHTTP := result.Details["http"].(bool)
-
-	result.Success = (hasTCP && tcpOK) || (hasPing && pingOK) || (hasHTTP && httpOK)
 	result.Duration = time.Since(startTime)
 
+	if result.Success {
+		result.Error = ""
+		result.ErrorClass = ""
+	}
+
 	if ctx.Err() != nil {
 		result.Success = false
 		result.Error = ctx.Err().Error()
+		result.ErrorClass = ClassifyError(ctx.Err())
+	}
+	result.Degraded = result.Degraded && result.Success
+
+	if nc.metrics != nil {
+		nc.metrics.UpdateTargetDegraded(label, result.Degraded)
 	}
 
 	return result
 }
 
-func (nc *Nexa) checkCorporate(ctx context.Context, hp config.HostPort) CheckResult {
-	startTime := time.Now()
-	result := CheckResult{
-		Type:      CheckTypeCorporate,
-		Host:      hp.Host,
-		Port:      hp.Port,
-		Details:   make(map[string]interface{}),
-		Timestamp: startTime,
+// targetProbes returns the probes configured for a target, falling back to
+// the defaults for its check type.
+func targetProbes(target Target) []string {
+	if len(target.Probes) > 0 {
+		return target.Probes
 	}
 
-	if hp.Port > 0 {
-		tcpOK := utils.Retry(nc.config.Attempts, nc.config.Backoff, func() bool {
-			if ctx.Err() != nil {
-				return false
-			}
-			return CheckTCP(hp.Host, hp.Port, nc.config.TCPTimeout)
-		})
-		result.Details["tcp"] = tcpOK
+	var names []string
+	if target.Port > 0 {
+		names = append(names, "tcp")
 	}
 
-	if nc.config.DNSProbe != "" {
-		dnsOK := utils.Retry(nc.config.Attempts, nc.config.Backoff, func() bool {
-			if ctx.Err() != nil {
-				return false
-			}
-			return CheckDNS(nc.config.DNSProbe)
-		})
-		result.Details["dns"] = dnsOK
-		result.Details["dns_probe"] = nc.config.DNSProbe
+	if target.Type != CheckTypeCorporate {
+		names = append(names, "ping")
 	}
-
-	tcpOK, hasTCP := result.Details["tcp"].(bool)
-	dnsOK, hasDNS := result.Details["dns"].(bool)
-
-	result.Success = (hasTCP && tcpOK) || (hasDNS && dnsOK)
-	result.Duration = time.Since(startTime)
-
-	if ctx.Err() != nil {
-		result.Success = false
-		result.Error = ctx.Err().Error()
+	if target.HTTPURL != "" {
+		names = append(names, "http")
 	}
-
-	return result
-}
-
-func (nc *Nexa) determineInternetStatus(result *GlobalResult) bool {
-	for _, check := range result.InternetDetails {
-		if check.Success {
-			return true
-		}
-	}
-
-	if nc.config.HTTPURL != "" {
-		httpOK := CheckHTTP(nc.config.HTTPURL, nc.config.HTTPTimeout)
-		if httpOK {
-			fallbackResult := CheckResult{
-				Type:    CheckTypeExternal,
-				Host:    nc.config.HTTPURL,
-				Success: true,
-				Details: map[string]interface{}{
-					"http":          true,
-					"http_fallback": true,
-				},
-				Timestamp: time.Now(),
-			}
-			result.InternetDetails["http_fallback"] = fallbackResult
-			return true
-		}
+	if target.DNSName != "" {
+		names = append(names, "dns")
+	}
+	if len(target.Scenario) > 0 {
+		names = append(names, "scenario")
+	}
+	if len(names) == 0 {
+		names = append(names, "ping")
 	}
 
-	return false
+	return names
 }
 
-func (nc *Nexa) determineCorporateStatus(result *GlobalResult) bool {
-	for _, check := range result.CorporateDetails {
-		if check.Success {
-			return true
+func configTargets(cfg *config.Config) []Target {
+	var targets []Target
+	for _, group := range configGroups(cfg) {
+		for _, hp := range group.Hosts {
+			if len(hp.Probes) == 0 {
+				hp.Probes = group.Probes
+			}
+			targets = append(targets, Target{HostPort: hp, Type: CheckType(group.Name)})
 		}
 	}
-	return false
+	return targets
 }
 
 func (nc *Nexa) calculateSummary(result *GlobalResult) types.SummaryStats {
@@ -298,6 +435,9 @@ func (nc *Nexa) calculateSummary(result *GlobalResult) types.SummaryStats {
 		stats.ExternalChecks++
 		if check.Success {
 			stats.Successful++
+			if check.Degraded {
+				stats.Degraded++
+			}
 		} else {
 			stats.Failed++
 		}
@@ -308,24 +448,80 @@ func (nc *Nexa) calculateSummary(result *GlobalResult) types.SummaryStats {
 		stats.CorporateChecks++
 		if check.Success {
 			stats.Successful++
+			if check.Degraded {
+				stats.Degraded++
+			}
+		} else {
+			stats.Failed++
+		}
+	}
+
+	for _, check := range result.Global {
+		stats.TotalChecks++
+		stats.GlobalChecks++
+		if check.Success {
+			stats.Successful++
+			if check.Degraded {
+				stats.Degraded++
+			}
 		} else {
 			stats.Failed++
 		}
 	}
+
+	for name, group := range result.Groups {
+		if isBuiltinGroup(name) {
+			continue
+		}
+		if stats.GroupChecks == nil {
+			stats.GroupChecks = make(map[string]int)
+		}
+		for _, check := range group.Details {
+			stats.TotalChecks++
+			stats.GroupChecks[name]++
+			if check.Success {
+				stats.Successful++
+				if check.Degraded {
+					stats.Degraded++
+				}
+			} else {
+				stats.Failed++
+			}
+		}
+	}
 	
 	return stats
 }
 
+// ExitCode returns 0 when every group is up. Otherwise it ORs together 1
+// for internet, 2 for corporate and the exit_code of each additional group
+// that is down.
 func (r *GlobalResult) ExitCode() int {
-	if r.InternetOK && r.CorporateOK {
-		return 0
-	} else if !r.InternetOK && r.CorporateOK {
-		return 1
-	} else if r.InternetOK && !r.CorporateOK {
-		return 2
-	} else {
-		return 3
+	code := 0
+	if !r.InternetOK {
+		code |= 1
+	}
+	if !r.CorporateOK {
+		code |= 2
+	}
+	for name, group := range r.Groups {
+		if !isBuiltinGroup(name) && !group.OK {
+			code |= group.ExitCode
+		}
 	}
+	return code
+}
+
+// extraGroups returns the names of the additional groups in sorted order.
+func (r *GlobalResult) extraGroups() []string {
+	var names []string
+	for name := range r.Groups {
+		if !isBuiltinGroup(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (r *GlobalResult) PrintJSON() {
@@ -339,15 +535,106 @@ func (r *GlobalResult) PrintJSON() {
 
 func (r *GlobalResult) PrintHuman() {
 	status := "✅"
-	if !r.InternetOK || !r.CorporateOK {
+	if r.ExitCode() != 0 {
 		status = "❌"
 	}
 	
 	fmt.Printf("NetCheck Results %s\n", status)
 	fmt.Printf("Internet:  %v\n", r.InternetOK)
 	fmt.Printf("Corporate: %v\n", r.CorporateOK)
+	if r.CaptivePortal {
+		fmt.Printf("Portal:    %s\n", portalLabel(r.PortalURL))
+	}
+	if r.DNSView != "" {
+		fmt.Printf("DNS view:  %s\n", r.DNSView)
+	}
+	if r.DNSHijacked {
+		fmt.Printf("DNS:       NXDOMAIN rewritten to %s\n", strings.Join(r.FakeAnswers, ", "))
+	}
+	if r.TLSIntercepted {
+		fmt.Printf("TLS:       intercepted by %s\n", r.ObservedIssuer)
+	}
+	for _, name := range r.extraGroups() {
+		fmt.Printf("%-10s %v\n", name+":", r.Groups[name].OK)
+	}
 	fmt.Printf("Duration:  %.3fs\n", r.ElapsedSeconds)
 	fmt.Printf("Checks:    %d total (%d external, %d corporate)\n", 
 		r.Summary.TotalChecks, r.Summary.ExternalChecks, r.Summary.CorporateChecks)
 	fmt.Printf("Success:   %d/%d\n", r.Summary.Successful, r.Summary.TotalChecks)
+	if r.Summary.Degraded > 0 {
+		fmt.Printf("Degraded:  %d\n", r.Summary.Degraded)
+	}
+
+	failures := r.failedChecks()
+	if len(failures) > 0 {
+		fmt.Println("Failures:")
+		keys := make([]string, 0, len(failures))
+		for key := range failures {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			check := failures[key]
+			fmt.Printf("  %s [%s] %s\n", key, check.ErrorClass, check.Error)
+		}
+	}
+
+	degraded := r.selectChecks(func(check CheckResult) bool { return check.Degraded })
+	if len(degraded) > 0 {
+		fmt.Println("Degraded checks:")
+		keys := make([]string, 0, len(degraded))
+		for key := range degraded {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("  %s %s\n", key, degradedReason(degraded[key]))
+		}
+	}
+}
+
+// degradedReason describes the degraded probes of a check.
+func degradedReason(check CheckResult) string {
+	var reasons []string
+	for name, probe := range check.Probes {
+		if !probe.Degraded {
+			continue
+		}
+		if probe.reason != "" {
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", name, probe.reason))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("[%s]", name))
+		}
+	}
+	sort.Strings(reasons)
+	return strings.Join(reasons, ", ")
+}
+
+// failedChecks returns all failed checks, run-level ones keyed as
+// "global:<probe>".
+func (r *GlobalResult) failedChecks() map[string]CheckResult {
+	return r.selectChecks(func(check CheckResult) bool { return !check.Success })
+}
+
+// selectChecks returns the global, group and per-target checks matching keep.
+func (r *GlobalResult) selectChecks(keep func(CheckResult) bool) map[string]CheckResult {
+	selected := make(map[string]CheckResult)
+	for name, check := range r.Global {
+		if keep(check) {
+			selected[string(CheckTypeGlobal)+":"+name] = check
+		}
+	}
+
+	all := []map[string]CheckResult{r.InternetDetails, r.CorporateDetails}
+	for _, name := range r.extraGroups() {
+		all = append(all, r.Groups[name].Details)
+	}
+	for _, details := range all {
+		for key, check := range details {
+			if keep(check) {
+				selected[key] = check
+			}
+		}
+	}
+	return selected
 }
\ No newline at end of file
@@ -1,8 +1,12 @@
 package checker
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,47 +22,56 @@ func TestCheckTCP_Success(t *testing.T) {
 	var port int
 	fmt.Sscanf(portStr, "%d", &port)
 	
-	result := CheckTCP(host, port, 2*time.Second)
-	if !result {
-		t.Errorf("Expected TCP check to pass for mock server")
+	_, err := CheckTCP(context.Background(), host, port, 2*time.Second)
+	if err != nil {
+		t.Errorf("Expected TCP check to pass for mock server: %v", err)
 	}
 }
 
 func TestCheckTCP_Failure(t *testing.T) {
-	result := CheckTCP("127.0.0.1", 9999, 1*time.Second)
-	if result {
+	_, err := CheckTCP(context.Background(), "127.0.0.1", 9999, 1*time.Second)
+	if err == nil {
 		t.Errorf("Expected TCP check to fail for closed port")
 	}
+	if class := ClassifyError(err); class != ErrorClassConnRefused {
+		t.Errorf("Expected %s, got %s", ErrorClassConnRefused, class)
+	}
 }
 
 func TestCheckTCP_Timeout(t *testing.T) {
-	result := CheckTCP("1.1.1.1", 81, 1*time.Nanosecond)
-	if result {
+	_, err := CheckTCP(context.Background(), "1.1.1.1", 81, 1*time.Nanosecond)
+	if err == nil {
 		t.Errorf("Expected TCP check to timeout")
 	}
+	if class := ClassifyError(err); class != ErrorClassConnTimeout {
+		t.Errorf("Expected %s, got %s", ErrorClassConnTimeout, class)
+	}
 }
 
 func TestCheckDNS_Success(t *testing.T) {
-	result := CheckDNS("localhost")
-	if !result {
-		t.Errorf("Expected DNS resolution to work for localhost")
+	_, err := CheckDNS(context.Background(), "localhost")
+	if err != nil {
+		t.Errorf("Expected DNS resolution to work for localhost: %v", err)
 	}
 }
 
 func TestCheckDNS_Failure(t *testing.T) {
-	result := CheckDNS("this-domain-should-never-exist-12345.invalid")
-	if result {
+	_, err := CheckDNS(context.Background(), "this-domain-should-never-exist-12345.invalid")
+	if err == nil {
 		t.Errorf("Expected DNS resolution to fail for invalid domain")
 	}
+	if class := ClassifyError(err); class != ErrorClassDNSNXDomain {
+		t.Errorf("Expected %s, got %s", ErrorClassDNSNXDomain, class)
+	}
 }
 
 func TestCheckHTTP_Success(t *testing.T) {
 	server, url := MockHTTPServer(t, 200)
 	defer server.Close()
 	
-	result := CheckHTTP(url, 5*time.Second)
-	if !result {
-		t.Errorf("Expected HTTP check to pass for 200 status")
+	_, err := CheckHTTP(context.Background(), url, 5*time.Second)
+	if err != nil {
+		t.Errorf("Expected HTTP check to pass for 200 status: %v", err)
 	}
 }
 
@@ -66,15 +79,18 @@ func TestCheckHTTP_Failure(t *testing.T) {
 	server, url := MockHTTPServer(t, 404)
 	defer server.Close()
 	
-	result := CheckHTTP(url, 5*time.Second)
-	if result {
+	_, err := CheckHTTP(context.Background(), url, 5*time.Second)
+	if err == nil {
 		t.Errorf("Expected HTTP check to fail for 404 status")
 	}
+	if class := ClassifyError(err); class != ErrorClassHTTPStatus {
+		t.Errorf("Expected %s, got %s", ErrorClassHTTPStatus, class)
+	}
 }
 
 func TestCheckHTTP_Timeout(t *testing.T) {
-	result := CheckHTTP("http://10.255.255.1", 100*time.Millisecond)
-	if result {
+	_, err := CheckHTTP(context.Background(), "http://10.255.255.1", 100*time.Millisecond)
+	if err == nil {
 		t.Errorf("Expected HTTP check to timeout")
 	}
 }
@@ -195,4 +211,504 @@ func TestCheckResult_Human(t *testing.T) {
 	}
 	
 	result.PrintHuman()
+}
+
+func TestNexaServe(t *testing.T) {
+	cfg := &config.Config{
+		TCPTimeout:  1 * time.Second,
+		HTTPTimeout: 1 * time.Second,
+		PingTimeout: 1 * time.Second,
+		Attempts:    1,
+		Workers:     4,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		checker.Serve(10*time.Millisecond, func(result *GlobalResult) {
+			runs++
+			if runs == 3 {
+				checker.Shutdown()
+			}
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Serve to stop after Shutdown")
+	}
+
+	if runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", runs)
+	}
+	if checker.LastResult() == nil {
+		t.Error("Expected last result to be kept")
+	}
+}
+
+func TestProbeRegistry(t *testing.T) {
+	names := ProbeNames()
+	for _, want := range []string{"dns", "http", "ping", "tcp"} {
+		found := false
+		for _, name := range names {
+			if name == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected built-in probe %q to be registered", want)
+		}
+	}
+}
+
+func TestNexaConfiguredProbes(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{
+			{Host: "up.example", Probes: []string{"mock-down", "mock-up"}},
+		},
+		CorpHosts: []config.HostPort{
+			{Host: "down.example", Probes: []string{"mock-down"}},
+		},
+		Attempts: 1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	external := result.InternetDetails["external:up.example:0"]
+	if !external.Success || external.Details["mock-up"] != true || external.Details["mock-down"] != false {
+		t.Errorf("Unexpected external result: %+v", external)
+	}
+	if len(external.Probes) != 2 {
+		t.Errorf("Expected 2 probe results, got %d", len(external.Probes))
+	}
+	if !result.InternetOK || result.CorporateOK {
+		t.Errorf("Expected internet up and corporate down, got %v/%v", result.InternetOK, result.CorporateOK)
+	}
+}
+
+func TestNexaUnknownProbe(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "fileserver.corp.local", Port: 445, Probes: []string{"carrier-pigeon"}},
+		},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for unknown probe")
+	}
+}
+
+func TestNexaTargetOverrides(t *testing.T) {
+	server, url := MockHTTPServer(t, 200)
+	defer server.Close()
+
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "intranet.corp.local", HTTPURL: url, DNSName: "localhost", Timeout: time.Second},
+		},
+		HTTPURL:      "http://10.255.255.1",
+		DNSProbe:     config.DNSProbe{Name: "this-domain-should-never-exist-12345.invalid"},
+		GlobalProbes: []string{},
+		Attempts:     1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	check := result.CorporateDetails["corporate:intranet.corp.local:0"]
+	if check.Details["http"] != true || check.Details["dns"] != true {
+		t.Errorf("Expected per-target http_url and dns_name to be used, got %+v", check.Details)
+	}
+	if got := check.Probes["http"].Details["http_url"]; got != url {
+		t.Errorf("Expected http_url %q, got %v", url, got)
+	}
+}
+
+func TestNexaTargetAttempts(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "slow.corp.local", Probes: []string{"mock-count"}, Attempts: 3},
+		},
+		Attempts: 1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	before := atomic.LoadInt32(&mockCounter.calls)
+	result := checker.Run()
+	if calls := atomic.LoadInt32(&mockCounter.calls) - before; calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	if got := result.CorporateDetails["corporate:slow.corp.local:0"].Probes["mock-count"].Attempts; got != 3 {
+		t.Errorf("Expected 3 attempts to be reported, got %d", got)
+	}
+}
+
+func TestNexaGroups(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{
+			{Host: "8.8.8.8", Probes: []string{"mock-up"}},
+		},
+		Groups: []config.Group{
+			{Name: "corporate", Probes: []string{"mock-up"}, Hosts: []config.HostPort{{Host: "dc01.corp.local"}}},
+			{Name: "vpn", Probes: []string{"mock-down"}, Hosts: []config.HostPort{{Host: "vpn-gw.corp.local"}}},
+			{Name: "lab", ExitCode: 8, Hosts: []config.HostPort{{Host: "lab01", Probes: []string{"mock-up"}}}},
+		},
+		Attempts: 1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	if !result.CorporateOK || len(result.CorporateDetails) != 1 {
+		t.Errorf("Expected corporate group to be extended, got %+v", result.CorporateDetails)
+	}
+	if result.Groups["vpn"].OK || !result.Groups["lab"].OK {
+		t.Errorf("Expected vpn down and lab up")
+	}
+	if _, ok := result.Groups["vpn"].Details["vpn:vpn-gw.corp.local:0"]; !ok {
+		t.Errorf("Expected vpn details to be keyed by group, got %+v", result.Groups["vpn"].Details)
+	}
+	if result.Summary.GroupChecks["vpn"] != 1 || result.Summary.TotalChecks != 4 {
+		t.Errorf("Unexpected summary: %+v", result.Summary)
+	}
+	if code := result.ExitCode(); code != DefaultGroupExitCode {
+		t.Errorf("Expected exit code %d, got %d", DefaultGroupExitCode, code)
+	}
+}
+
+func TestGlobalResultExitCodes_Groups(t *testing.T) {
+	result := &GlobalResult{
+		InternetOK:  false,
+		CorporateOK: true,
+		Groups: map[string]*GroupResult{
+			"vpn": {OK: false, ExitCode: 4},
+			"lab": {OK: false, ExitCode: 8},
+			"dc":  {OK: true, ExitCode: 16},
+		},
+	}
+	if got := result.ExitCode(); got != 13 {
+		t.Errorf("Expected exit code 13, got %d", got)
+	}
+}
+
+func TestNexaDuplicateGroup(t *testing.T) {
+	cfg := &config.Config{
+		Groups: []config.Group{{Name: "vpn"}, {Name: "vpn"}},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for duplicate group")
+	}
+}
+
+func TestNexaGroupExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		wantErr  bool
+	}{
+		{"default", 0, false},
+		{"explicit", 8, false},
+		{"highest", 255, false},
+		{"internet bit", 1, true},
+		{"corporate bit", 2, true},
+		{"negative", -1, true},
+		{"too large", 256, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Groups: []config.Group{{Name: "vpn", ExitCode: tt.exitCode}},
+			}
+			_, err := NewNexa(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNexaReservedGroup(t *testing.T) {
+	cfg := &config.Config{
+		Groups: []config.Group{{Name: "global", Hosts: []config.HostPort{{Host: "127.0.0.1", Port: 80}}}},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for a group named global")
+	}
+}
+
+func TestNexaGroupPolicy(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "dc01.corp.local", Probes: []string{"mock-up"}},
+			{Host: "dc02.corp.local", Probes: []string{"mock-down"}},
+		},
+		Groups: []config.Group{
+			{Name: "corporate", Policy: "all"},
+		},
+		Attempts: 1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	corporate := result.Groups["corporate"]
+	if result.CorporateOK || corporate.Policy != "all" {
+		t.Errorf("Expected corporate down under the all policy, got %+v", corporate)
+	}
+	if corporate.PassedWeight != 1 || corporate.TotalWeight != 2 {
+		t.Errorf("Expected weight 1/2, got %d/%d", corporate.PassedWeight, corporate.TotalWeight)
+	}
+}
+
+func TestNexaNegativeWeight(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "dc01.corp.local", Probes: []string{"mock-up"}, Weight: -1}},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for a negative weight")
+	}
+}
+
+func TestNexaInvalidPolicy(t *testing.T) {
+	cfg := &config.Config{
+		Groups: []config.Group{{Name: "vpn", Policy: "most"}},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for invalid policy")
+	}
+}
+
+func TestNexaErrorClass(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "dc01.corp.local", Probes: []string{"mock-down"}},
+		},
+		Attempts: 1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	check := result.CorporateDetails["corporate:dc01.corp.local:0"]
+	if check.ErrorClass != ErrorClassConnRefused || check.Error != "mock failure" {
+		t.Errorf("Expected classified error on check, got %q/%q", check.ErrorClass, check.Error)
+	}
+	if probe := check.Probes["mock-down"]; probe.ErrorClass != ErrorClassConnRefused {
+		t.Errorf("Expected classified error on probe, got %q", probe.ErrorClass)
+	}
+
+	result.PrintHuman()
+}
+
+func TestNexaProbeLatency(t *testing.T) {
+	addr, cleanup := MockTCPServer(t)
+	defer cleanup()
+
+	host, portStr, _ := net.SplitHostPort(addr)
+	var port int
+	fmt.Sscanf(portStr, "%d", &port)
+
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: host, Port: port, Probes: []string{"tcp"}},
+		},
+		TCPTimeout: 2 * time.Second,
+		Attempts:   2,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	probe := result.CorporateDetails[fmt.Sprintf("corporate:%s:%d", host, port)].Probes["tcp"]
+	if !probe.Success || probe.LatencyMs <= 0 {
+		t.Errorf("Expected connect latency to be measured, got %+v", probe)
+	}
+	if probe.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", probe.Attempts)
+	}
+}
+
+func TestCheckHTTP_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := CheckHTTP(ctx, server.URL, 10*time.Second)
+	if class := ClassifyError(err); class != ErrorClassCancelled {
+		t.Errorf("Expected %s, got %s (%v)", ErrorClassCancelled, class, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to interrupt the request, took %v", elapsed)
+	}
+}
+
+func TestCheckDNS_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := CheckDNS(ctx, "example.com"); err == nil {
+		t.Error("Expected DNS lookup to fail with a cancelled context")
+	}
+}
+
+func TestNexaShutdownInterruptsRetries(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{
+			{Host: "dc01.corp.local", Probes: []string{"mock-down"}},
+		},
+		Attempts: 5,
+		Backoff:  10 * time.Second,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, checker.Shutdown)
+
+	start := time.Now()
+	result := checker.Run()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Shutdown to interrupt the retry backoff, took %v", elapsed)
+	}
+	if check := result.CorporateDetails["corporate:dc01.corp.local:0"]; check.ErrorClass != ErrorClassCancelled {
+		t.Errorf("Expected cancelled check, got %q", check.ErrorClass)
+	}
+}
+
+func TestNexaGlobalChecks(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{
+			{Host: "8.8.8.8", Probes: []string{"mock-down"}},
+			{Host: "1.1.1.1", Probes: []string{"mock-down"}},
+			{Host: "8.8.4.4", Probes: []string{"mock-down"}},
+		},
+		CorpHosts: []config.HostPort{
+			{Host: "dc01.corp.local", Probes: []string{"mock-down"}},
+		},
+		Groups: []config.Group{
+			{Name: "vpn", GlobalChecks: []string{"dns"}, Hosts: []config.HostPort{{Host: "vpn-gw", Probes: []string{"mock-down"}}}},
+		},
+		HTTPURL:     server.URL,
+		HTTPTimeout: 5 * time.Second,
+		DNSProbe:    config.DNSProbe{Name: "localhost"},
+		Attempts:    1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected the global http check to run once, got %d requests", got)
+	}
+	if len(result.Global) != 2 || !result.Global["http"].Success || !result.Global["dns"].Success {
+		t.Errorf("Expected successful http and dns global checks, got %+v", result.Global)
+	}
+	if !result.InternetOK || !result.CorporateOK || !result.Groups["vpn"].OK {
+		t.Errorf("Expected groups to be up through global checks")
+	}
+	if external := result.Groups["external"]; external.TotalWeight != 4 || external.PassedWeight != 1 {
+		t.Errorf("Expected global http to count once in the external policy, got %d/%d",
+			external.PassedWeight, external.TotalWeight)
+	}
+	if result.Summary.GlobalChecks != 2 {
+		t.Errorf("Expected 2 global checks in summary, got %d", result.Summary.GlobalChecks)
+	}
+}
+
+func TestNexaGlobalProbes(t *testing.T) {
+	tests := []struct {
+		name    string
+		probes  []string
+		wantErr bool
+	}{
+		{"run-level probes", []string{"http", "dns", "split_horizon"}, false},
+		{"unknown probe", []string{"smtp"}, true},
+		{"probe that needs a host", []string{"tcp"}, true},
+		{"duplicate probe", []string{"dns", "dns"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{GlobalProbes: tt.probes}
+			_, err := NewNexa(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMetricTarget(t *testing.T) {
+	global := Target{HostPort: config.HostPort{Probes: []string{"dns"}}, Type: CheckTypeGlobal}
+	if got := metricTarget(global); got != "global:dns" {
+		t.Errorf("Expected global:dns, got %q", got)
+	}
+	host := Target{HostPort: config.HostPort{Host: "10.0.0.1", Port: 443}, Type: CheckTypeCorporate}
+	if got := metricTarget(host); got != "10.0.0.1:443" {
+		t.Errorf("Expected 10.0.0.1:443, got %q", got)
+	}
+}
+
+func TestNexaUnknownGlobalCheck(t *testing.T) {
+	cfg := &config.Config{
+		Groups: []config.Group{{Name: "vpn", GlobalChecks: []string{"http"}}},
+	}
+
+	if _, err := NewNexa(cfg); err == nil {
+		t.Error("Expected error for a global check that does not run")
+	}
 }
\ No newline at end of file
@@ -1,8 +1,507 @@
 package checker
 
-import "net"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
 
-func CheckDNS(hostname string) bool {
-	_, err := net.LookupHost(hostname)
-	return err == nil
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("dns", newDNSProbe)
+}
+
+// DefaultDNSPort is used for servers given without a port.
+const DefaultDNSPort = "53"
+
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"SRV":   dnsmessage.TypeSRV,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+}
+
+type dnsProbe struct {
+	query   config.DNSProbe
+	timeout time.Duration
+}
+
+func newDNSProbe(cfg *config.Config) Probe {
+	return &dnsProbe{query: cfg.DNSProbe, timeout: cfg.DNSTimeout}
+}
+
+func (p *dnsProbe) Name() string {
+	return "dns"
+}
+
+func (p *dnsProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	query := targetDNSQuery(p.query, target)
+	if query.Name == "" {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, errors.New("no dns_probe configured")))
+	}
+
+	answer, latency, err := QueryDNS(ctx, query, target.timeout(p.timeout))
+	if err == nil {
+		err = answer.expect(query.Expect)
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = answer.details(query)
+	return result
+}
+
+// targetDNSQuery applies a target's dns block and dns_name over the global
+// dns_probe.
+func targetDNSQuery(query config.DNSProbe, target Target) config.DNSProbe {
+	override := target.DNS
+	if override.Name != "" {
+		query.Name = override.Name
+	}
+	if target.DNSName != "" {
+		query.Name = target.DNSName
+	}
+	if override.Type != "" {
+		query.Type = override.Type
+	}
+	if override.Server != "" {
+		query.Server = override.Server
+	}
+	if override.Protocol != "" {
+		query.Protocol = override.Protocol
+	}
+	if override.URL != "" {
+		query.URL = override.URL
+	}
+	if override.Expect != nil {
+		query.Expect = override.Expect
+	}
+	return query
+}
+
+// DNSAnswer is the response to a DNS query. TTL is the lowest TTL among the
+// answer records and is unknown for the system resolver.
+type DNSAnswer struct {
+	Server  string
+	RCode   string
+	Answers []string
+	TTL     *uint32
+}
+
+// expect checks that every expected value is among the answers.
+func (a *DNSAnswer) expect(values []string) error {
+	for _, want := range values {
+		found := false
+		for _, got := range a.Answers {
+			if normalizeAnswer(got) == normalizeAnswer(want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return newProbeError(ErrorClassDNSMismatch,
+				fmt.Errorf("expected %q among answers %v", want, a.Answers))
+		}
+	}
+	return nil
+}
+
+func (a *DNSAnswer) details(query config.DNSProbe) map[string]interface{} {
+	details := map[string]interface{}{
+		"dns_probe": query.Name,
+		"type":      dnsType(query),
+		"server":    a.Server,
+	}
+	if a.RCode != "" {
+		details["rcode"] = a.RCode
+		details["answers"] = a.Answers
+		details["answer_count"] = len(a.Answers)
+		if a.TTL != nil {
+			details["ttl"] = *a.TTL
+		}
+	}
+	return details
+}
+
+func normalizeAnswer(s string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+}
+
+func dnsType(query config.DNSProbe) string {
+	if query.Type == "" {
+		return "A"
+	}
+	return strings.ToUpper(query.Type)
+}
+
+// CheckDNS resolves hostname and returns the resolution time.
+func CheckDNS(ctx context.Context, hostname string) (time.Duration, error) {
+	start := time.Now()
+	_, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	return time.Since(start), err
+}
+
+// QueryDNS resolves query and returns the answer and the query latency.
+// Queries without a server use the system resolver, which reports neither
+// rcode nor TTL. The returned answer is never nil.
+func QueryDNS(ctx context.Context, query config.DNSProbe, timeout time.Duration) (*DNSAnswer, time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	qtype, ok := dnsTypes[dnsType(query)]
+	if !ok {
+		return &DNSAnswer{}, 0, newProbeError(ErrorClassConfig, fmt.Errorf("unsupported record type %q", query.Type))
+	}
+	if query.Server == "" {
+		return lookupSystem(ctx, query.Name, qtype)
+	}
+	return exchangeDNS(ctx, dnsServer(query.Server), dnsProtocol(query), queryName(query.Name, qtype), qtype)
+}
+
+// queryName returns the name sent on the wire, which is the reverse lookup
+// name for PTR queries given an IP address.
+func queryName(name string, qtype dnsmessage.Type) string {
+	if qtype == dnsmessage.TypePTR {
+		if arpa, err := reverseName(name); err == nil {
+			return arpa
+		}
+	}
+	return name
+}
+
+func dnsServer(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), DefaultDNSPort)
+}
+
+func dnsProtocol(query config.DNSProbe) string {
+	if query.Protocol == "" {
+		return "udp"
+	}
+	return strings.ToLower(query.Protocol)
+}
+
+// lookupSystem resolves name with the system resolver.
+func lookupSystem(ctx context.Context, name string, qtype dnsmessage.Type) (*DNSAnswer, time.Duration, error) {
+	answer := &DNSAnswer{Server: "system"}
+	resolver := net.DefaultResolver
+
+	start := time.Now()
+	var err error
+	switch qtype {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		var addrs []net.IPAddr
+		addrs, err = resolver.LookupIPAddr(ctx, name)
+		for _, addr := range addrs {
+			if (addr.IP.To4() != nil) == (qtype == dnsmessage.TypeA) {
+				answer.Answers = append(answer.Answers, addr.IP.String())
+			}
+		}
+	case dnsmessage.TypeCNAME:
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, name)
+		if err == nil {
+			answer.Answers = []string{cname}
+		}
+	case dnsmessage.TypeSRV:
+		var srvs []*net.SRV
+		_, srvs, err = resolver.LookupSRV(ctx, "", "", name)
+		for _, srv := range srvs {
+			answer.Answers = append(answer.Answers, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, srv.Target))
+		}
+	case dnsmessage.TypeMX:
+		var mxs []*net.MX
+		mxs, err = resolver.LookupMX(ctx, name)
+		for _, mx := range mxs {
+			answer.Answers = append(answer.Answers, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case dnsmessage.TypeTXT:
+		answer.Answers, err = resolver.LookupTXT(ctx, name)
+	case dnsmessage.TypePTR:
+		answer.Answers, err = resolver.LookupAddr(ctx, name)
+	default:
+		err = newProbeError(ErrorClassConfig, fmt.Errorf("%s queries need a server", typeName(qtype)))
+	}
+	latency := time.Since(start)
+
+	if err != nil {
+		return answer, latency, err
+	}
+	if len(answer.Answers) == 0 {
+		return answer, latency, newProbeError(ErrorClassDNSFailure, fmt.Errorf("no %s records for %s", typeName(qtype), name))
+	}
+	return answer, latency, nil
+}
+
+// dnsID returns a random transaction ID. It comes from crypto/rand so that
+// off-path attackers cannot predict it and spoof a response.
+func dnsID() (uint16, error) {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b[:]), nil
+}
+
+// exchangeDNS sends a single query to server and parses the response. A
+// truncated UDP response is retried over TCP.
+func exchangeDNS(ctx context.Context, server, protocol, name string, qtype dnsmessage.Type) (*DNSAnswer, time.Duration, error) {
+	answer := &DNSAnswer{Server: server}
+
+	id, err := dnsID()
+	if err != nil {
+		return answer, 0, err
+	}
+	packed, err := packDNSQuery(name, qtype, id)
+	if err != nil {
+		return answer, 0, err
+	}
+
+	start := time.Now()
+	resp, err := roundTripDNS(ctx, server, protocol, packed, id)
+	if err == nil && resp.Truncated && protocol == "udp" {
+		resp, err = roundTripDNS(ctx, server, "tcp", packed, id)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		return answer, latency, err
+	}
+	return answer, latency, answer.fill(resp, name, qtype)
+}
+
+// packDNSQuery builds a recursive query for name.
+func packDNSQuery(name string, qtype dnsmessage.Type, id uint16) ([]byte, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, newProbeError(ErrorClassConfig, err)
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, newProbeError(ErrorClassConfig, err)
+	}
+	return packed, nil
+}
+
+// fill records the rcode, answers and lowest TTL of resp and returns an
+// error for NXDOMAIN, other failure rcodes and empty answers.
+func (a *DNSAnswer) fill(resp *dnsmessage.Message, name string, qtype dnsmessage.Type) error {
+	a.RCode = rcodeName(resp.RCode)
+	for _, rr := range resp.Answers {
+		if a.TTL == nil || rr.Header.TTL < *a.TTL {
+			ttl := rr.Header.TTL
+			a.TTL = &ttl
+		}
+		if rr.Header.Type == qtype {
+			a.Answers = append(a.Answers, formatRecord(rr.Body))
+		}
+	}
+
+	switch {
+	case resp.RCode == dnsmessage.RCodeNameError:
+		return newProbeError(ErrorClassDNSNXDomain, fmt.Errorf("%s: NXDOMAIN from %s", name, a.Server))
+	case resp.RCode != dnsmessage.RCodeSuccess:
+		return newProbeError(ErrorClassDNSFailure, fmt.Errorf("%s: %s from %s", name, a.RCode, a.Server))
+	case len(a.Answers) == 0:
+		return newProbeError(ErrorClassDNSFailure, fmt.Errorf("no %s records for %s from %s", typeName(qtype), name, a.Server))
+	}
+	return nil
+}
+
+// roundTripDNS exchanges one message with server, framing it with a length
+// prefix over TCP and skipping responses with a different ID over UDP.
+func roundTripDNS(ctx context.Context, server, protocol string, packed []byte, id uint16) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, protocol, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if protocol == "tcp" {
+		return roundTripStream(ctx, conn, packed, id)
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, dnsIOError(ctx, err)
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, dnsIOError(ctx, err)
+		}
+		if resp, err := parseDNSResponse(buf[:n], id); err == nil {
+			return resp, nil
+		}
+	}
+}
+
+// roundTripStream exchanges one length-prefixed message over a stream
+// connection, as used by DNS over TCP and TLS.
+func roundTripStream(ctx context.Context, conn net.Conn, packed []byte, id uint16) (*dnsmessage.Message, error) {
+	frame := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(frame, uint16(len(packed)))
+	copy(frame[2:], packed)
+	if _, err := conn.Write(frame); err != nil {
+		return nil, dnsIOError(ctx, err)
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, dnsIOError(ctx, err)
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, dnsIOError(ctx, err)
+	}
+	return parseDNSResponse(buf, id)
+}
+
+func parseDNSResponse(buf []byte, id uint16) (*dnsmessage.Message, error) {
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, newProbeError(ErrorClassDNSFailure, fmt.Errorf("malformed DNS response: %v", err))
+	}
+	if resp.ID != id || !resp.Response {
+		return nil, newProbeError(ErrorClassDNSFailure, errors.New("unexpected DNS response ID"))
+	}
+	return &resp, nil
+}
+
+// dnsIOError reports a deadline hit while talking to a server as a DNS
+// timeout, or the context error if the probe was cancelled.
+func dnsIOError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		return newProbeError(ErrorClassDNSTimeout, fmt.Errorf("DNS query timed out: %w", err))
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return newProbeError(ErrorClassDNSTimeout, fmt.Errorf("DNS query timed out: %w", err))
+	}
+	return err
+}
+
+func rcodeName(rcode dnsmessage.RCode) string {
+	// dnsmessage names rcodes like "RCodeNameError"; report the names used
+	// by dig instead.
+	switch rcode {
+	case dnsmessage.RCodeSuccess:
+		return "NOERROR"
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// formatRecord renders a record body in dig-like presentation format.
+func formatRecord(body dnsmessage.ResourceBody) string {
+	switch rr := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(rr.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(rr.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return rr.CNAME.String()
+	case *dnsmessage.PTRResource:
+		return rr.PTR.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", rr.Pref, rr.MX)
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", rr.Priority, rr.Weight, rr.Port, rr.Target)
+	case *dnsmessage.TXTResource:
+		return strings.Join(rr.TXT, "")
+	case *dnsmessage.SOAResource:
+		return fmt.Sprintf("%s %s %d", rr.NS, rr.MBox, rr.Serial)
+	}
+	return body.GoString()
+}
+
+func typeName(qtype dnsmessage.Type) string {
+	return strings.TrimPrefix(qtype.String(), "Type")
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// reverseName returns the in-addr.arpa or ip6.arpa name of an IP address.
+func reverseName(addr string) (string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", fmt.Errorf("%q is not an IP address", addr)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String(), nil
+}
+
+// validateDNSQuery rejects unsupported record types and protocols.
+func validateDNSQuery(query config.DNSProbe) error {
+	if _, ok := dnsTypes[dnsType(query)]; !ok {
+		return fmt.Errorf("dns: unsupported record type %q", query.Type)
+	}
+	switch dnsProtocol(query) {
+	case "udp", "tcp":
+	default:
+		return fmt.Errorf("dns: unsupported protocol %q, expected udp or tcp", query.Protocol)
+	}
+	if query.URL != "" {
+		if u, err := url.Parse(query.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("dns: invalid DoH url %q, expected https://host/path", query.URL)
+		}
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,206 @@
+package checker
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// zone maps "name type" to the records a mock DNS server returns. A "*"
+// name answers every name of that type; other names get NXDOMAIN.
+type zone map[string][]dnsmessage.Resource
+
+func (z zone) answer(query []byte) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) == 0 {
+		return nil
+	}
+	q := msg.Questions[0]
+
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, RecursionAvailable: true},
+		Questions: msg.Questions,
+	}
+	records, ok := z[q.Name.String()+" "+typeName(q.Type)]
+	if !ok {
+		records, ok = z["* "+typeName(q.Type)]
+	}
+	if !ok {
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+	for _, rr := range records {
+		rr.Header.Name = q.Name
+		rr.Header.Class = dnsmessage.ClassINET
+		resp.Answers = append(resp.Answers, rr)
+	}
+	packed, _ := resp.Pack()
+	return packed
+}
+
+// newDNSServer serves z over UDP and TCP and returns both addresses.
+func newDNSServer(t *testing.T, z zone) (udpAddr, tcpAddr string) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(z.answer(buf[:n]), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					resp := z.answer(query)
+					binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+					conn.Write(append(length[:], resp...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return pc.LocalAddr().String(), ln.Addr().String()
+}
+
+func testZone() zone {
+	return zone{
+		"dc01.corp.local. A": {{
+			Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA, TTL: 300},
+			Body:   &dnsmessage.AResource{A: [4]byte{10, 0, 0, 10}},
+		}},
+		"dc01.corp.local. AAAA": {},
+		"_ldap._tcp.corp.local. SRV": {{
+			Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeSRV, TTL: 600},
+			Body:   &dnsmessage.SRVResource{Priority: 0, Weight: 100, Port: 389, Target: dnsmessage.MustNewName("dc01.corp.local.")},
+		}},
+		"corp.local. MX": {{
+			Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeMX, TTL: 60},
+			Body:   &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.corp.local.")},
+		}},
+		"10.0.0.10.in-addr.arpa. PTR": {{
+			Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypePTR, TTL: 60},
+			Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("dc01.corp.local.")},
+		}},
+	}
+}
+
+func TestDNSProbe_Server(t *testing.T) {
+	udpAddr, tcpAddr := newDNSServer(t, testZone())
+	probe := newDNSProbe(&config.Config{DNSTimeout: 2 * time.Second})
+
+	tests := []struct {
+		name    string
+		query   config.DNSProbe
+		class   ErrorClass
+		answers []string
+	}{
+		{"A over UDP", config.DNSProbe{Name: "dc01.corp.local", Server: udpAddr, Expect: []string{"10.0.0.10"}}, "", []string{"10.0.0.10"}},
+		{"A over TCP", config.DNSProbe{Name: "dc01.corp.local", Server: tcpAddr, Protocol: "tcp"}, "", []string{"10.0.0.10"}},
+		{"SRV", config.DNSProbe{Name: "_ldap._tcp.corp.local", Type: "srv", Server: udpAddr}, "", []string{"0 100 389 dc01.corp.local."}},
+		{"MX", config.DNSProbe{Name: "corp.local", Type: "MX", Server: udpAddr, Expect: []string{"10 mail.corp.local"}}, "", []string{"10 mail.corp.local."}},
+		{"PTR", config.DNSProbe{Name: "10.0.0.10", Type: "PTR", Server: udpAddr}, "", []string{"dc01.corp.local."}},
+		{"unexpected answer", config.DNSProbe{Name: "dc01.corp.local", Server: udpAddr, Expect: []string{"10.0.0.11"}}, ErrorClassDNSMismatch, []string{"10.0.0.10"}},
+		{"NXDOMAIN", config.DNSProbe{Name: "missing.corp.local", Server: udpAddr}, ErrorClassDNSNXDomain, nil},
+		{"no data", config.DNSProbe{Name: "dc01.corp.local", Type: "AAAA", Server: udpAddr}, ErrorClassDNSFailure, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := probe.Execute(context.Background(), Target{HostPort: config.HostPort{DNS: tt.query}})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			answers, _ := result.Details["answers"].([]string)
+			if len(answers) != len(tt.answers) || len(answers) > 0 && answers[0] != tt.answers[0] {
+				t.Errorf("Expected answers %v, got %v", tt.answers, answers)
+			}
+			if tt.class == "" && (result.Details["rcode"] != "NOERROR" || result.Details["ttl"] == nil) {
+				t.Errorf("Expected NOERROR with a TTL, got %v", result.Details)
+			}
+		})
+	}
+}
+
+func TestDNSProbe_Timeout(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	query := config.DNSProbe{Name: "dc01.corp.local", Server: pc.LocalAddr().String()}
+	_, _, err = QueryDNS(context.Background(), query, 100*time.Millisecond)
+	if class := ClassifyError(err); class != ErrorClassDNSTimeout {
+		t.Errorf("Expected error class %s, got %s (%v)", ErrorClassDNSTimeout, class, err)
+	}
+}
+
+func TestLookupSystem_CNAMEError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	answer, _, err := lookupSystem(ctx, "nexa-test.invalid", dnsmessage.TypeCNAME)
+	if err == nil {
+		t.Skip("nexa-test.invalid resolved")
+	}
+	if len(answer.Answers) != 0 {
+		t.Errorf("Expected no answers on error, got %q", answer.Answers)
+	}
+}
+
+func TestNexaInvalidDNSType(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "dc01", Probes: []string{"dns"}, DNS: config.DNSProbe{Name: "corp.local", Type: "NS"}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an unsupported record type")
+	}
+
+	// dns_probe is validated even without targets.
+	cfg = &config.Config{DNSProbe: config.DNSProbe{Name: "corp.local", Protocol: "quic"}}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an unsupported dns_probe protocol")
+	}
+}
+
+func TestDNSID(t *testing.T) {
+	seen := make(map[uint16]bool)
+	for i := 0; i < 8; i++ {
+		id, err := dnsID()
+		if err != nil {
+			t.Fatalf("dnsID: %v", err)
+		}
+		seen[id] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected random transaction IDs, got %v", seen)
+	}
+}
\ No newline at end of file
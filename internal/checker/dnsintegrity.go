@@ -0,0 +1,199 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("dns_integrity", newDNSIntegrityProbe)
+}
+
+// Per-resolver verdicts reported by the dns_integrity probe.
+const (
+	IntegrityClean    = "clean"
+	IntegrityHijacked = "hijacked"
+	IntegrityError    = "error"
+)
+
+type dnsIntegrityProbe struct {
+	cfg     config.DNSIntegrity
+	timeout time.Duration
+}
+
+func newDNSIntegrityProbe(cfg *config.Config) Probe {
+	return &dnsIntegrityProbe{cfg: cfg.DNSIntegrity, timeout: cfg.DNSTimeout}
+}
+
+func (p *dnsIntegrityProbe) Name() string {
+	return "dns_integrity"
+}
+
+// resolverCheck is the outcome of the random lookups sent to one resolver.
+type resolverCheck struct {
+	verdict string
+	fake    []string
+	err     error
+}
+
+// Execute resolves random names under the configured suffix through every
+// resolver. Each name must come back as NXDOMAIN; a resolver that answers
+// rewrites NXDOMAIN and its answers are reported as fake.
+func (p *dnsIntegrityProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if p.cfg.Suffix == "" {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, errors.New("no dns_integrity suffix configured")))
+	}
+
+	resolvers := p.cfg.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = []string{SystemResolver}
+	}
+	names := randomNames(p.cfg.Suffix, p.cfg.Samples)
+
+	start := time.Now()
+	checks := p.checkAll(ctx, resolvers, names, target.timeout(p.timeout))
+	latency := time.Since(start)
+
+	verdicts := make(map[string]string, len(checks))
+	var hijacked, fake []string
+	var failure error
+	for _, resolver := range resolvers {
+		check := checks[resolver]
+		verdicts[resolver] = check.verdict
+		switch check.verdict {
+		case IntegrityHijacked:
+			hijacked = append(hijacked, resolver)
+			fake = append(fake, check.fake...)
+		case IntegrityError:
+			if failure == nil {
+				failure = fmt.Errorf("%s: %w", resolver, check.err)
+			}
+		}
+	}
+	fake = uniqueSorted(fake)
+
+	var err error
+	switch {
+	case len(hijacked) > 0:
+		err = newProbeError(ErrorClassDNSHijacked, fmt.Errorf("NXDOMAIN rewritten by %s to %s",
+			strings.Join(hijacked, ", "), strings.Join(fake, ", ")))
+	case failure != nil:
+		err = newProbeError(ClassifyError(failure), failure)
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = map[string]interface{}{
+		"suffix":    p.cfg.Suffix,
+		"names":     names,
+		"resolvers": verdicts,
+	}
+	if len(hijacked) > 0 {
+		result.Details["hijacked_resolvers"] = hijacked
+		result.Details["fake_answers"] = fake
+	}
+	return result
+}
+
+// checkAll queries the names through every resolver concurrently.
+func (p *dnsIntegrityProbe) checkAll(ctx context.Context, resolvers, names []string, timeout time.Duration) map[string]resolverCheck {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checks := make(map[string]resolverCheck, len(resolvers))
+	for _, resolver := range resolvers {
+		wg.Add(1)
+		go func(resolver string) {
+			defer wg.Done()
+			check := checkResolver(ctx, resolver, names, timeout)
+			mu.Lock()
+			checks[resolver] = check
+			mu.Unlock()
+		}(resolver)
+	}
+	wg.Wait()
+	return checks
+}
+
+// checkResolver looks up each name through resolver. Any answer marks the
+// resolver as hijacked; otherwise one NXDOMAIN or empty NOERROR response is
+// enough to call it clean.
+func checkResolver(ctx context.Context, resolver string, names []string, timeout time.Duration) resolverCheck {
+	check := resolverCheck{verdict: IntegrityError}
+	clean := false
+	for _, name := range names {
+		query := config.DNSProbe{Name: name}
+		if resolver != SystemResolver {
+			query.Server = resolver
+		}
+
+		answer, _, err := QueryDNS(ctx, query, timeout)
+		switch {
+		case len(answer.Answers) > 0:
+			check.fake = append(check.fake, answer.Answers...)
+		case ClassifyError(err) == ErrorClassDNSNXDomain, answer.RCode == "NOERROR":
+			clean = true
+		default:
+			check.err = err
+		}
+	}
+
+	switch {
+	case len(check.fake) > 0:
+		check.verdict = IntegrityHijacked
+	case clean:
+		check.verdict = IntegrityClean
+	}
+	return check
+}
+
+// randomNames returns n names under suffix that are very unlikely to exist.
+func randomNames(suffix string, n int) []string {
+	if n <= 0 {
+		n = 1
+	}
+	suffix = strings.Trim(suffix, ".")
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("nexa-%016x.%s", rand.Uint64(), suffix)
+	}
+	return names
+}
+
+func uniqueSorted(values []string) []string {
+	set := answerSet(values)
+	unique := make([]string, 0, len(set))
+	for v := range set {
+		unique = append(unique, v)
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// dnsHijack returns the resolvers that rewrote NXDOMAIN in the run-level
+// dns_integrity check and the fake answers they returned.
+func dnsHijack(global map[string]CheckResult) (bool, []string, []string) {
+	details := global["dns_integrity"].Probes["dns_integrity"].Details
+	resolvers, _ := details["hijacked_resolvers"].([]string)
+	fake, _ := details["fake_answers"].([]string)
+	return len(resolvers) > 0, resolvers, fake
+}
+
+func validateDNSIntegrity(cfg *config.Config) error {
+	if !cfg.DNSIntegrity.Enabled {
+		return nil
+	}
+	if strings.Trim(cfg.DNSIntegrity.Suffix, ".") == "" {
+		return errors.New("dns_integrity needs a suffix")
+	}
+	if cfg.DNSIntegrity.Samples < 0 {
+		return fmt.Errorf("dns_integrity samples must not be negative, got %d", cfg.DNSIntegrity.Samples)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func TestDNSIntegrityProbe(t *testing.T) {
+	clean, _ := newDNSServer(t, testZone())
+	hijacking, _ := newDNSServer(t, zone{"* A": {{
+		Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA, TTL: 60},
+		Body:   &dnsmessage.AResource{A: [4]byte{198, 51, 100, 7}},
+	}}})
+
+	newProbe := func(resolvers ...string) Probe {
+		return newDNSIntegrityProbe(&config.Config{
+			DNSTimeout:   2 * time.Second,
+			DNSIntegrity: config.DNSIntegrity{Enabled: true, Suffix: "example.com", Samples: 2, Resolvers: resolvers},
+		})
+	}
+
+	result := newProbe(clean).Execute(context.Background(), Target{})
+	if !result.Success {
+		t.Fatalf("Expected a clean resolver to pass, got %s", result.Error)
+	}
+	for _, name := range result.Details["names"].([]string) {
+		if !strings.HasSuffix(name, ".example.com") {
+			t.Errorf("Expected random names under example.com, got %s", name)
+		}
+	}
+
+	result = newProbe(clean, hijacking).Execute(context.Background(), Target{})
+	if result.ErrorClass != ErrorClassDNSHijacked {
+		t.Fatalf("Expected error class %s, got %q (%s)", ErrorClassDNSHijacked, result.ErrorClass, result.Error)
+	}
+	verdicts := result.Details["resolvers"].(map[string]string)
+	if verdicts[clean] != IntegrityClean || verdicts[hijacking] != IntegrityHijacked {
+		t.Errorf("Expected only %s to be hijacked, got %v", hijacking, verdicts)
+	}
+
+	hijacked, resolvers, fake := dnsHijack(map[string]CheckResult{
+		"dns_integrity": {Probes: map[string]ProbeResult{"dns_integrity": result}},
+	})
+	if !hijacked || len(resolvers) != 1 || len(fake) != 1 || fake[0] != "198.51.100.7" {
+		t.Errorf("Expected the fake answer 198.51.100.7 from one resolver, got %v %v", resolvers, fake)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,243 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("dot", newDoTProbe)
+	RegisterProbe("doh", newDoHProbe)
+}
+
+// DefaultDoTPort is used by the dot probe for targets without a port.
+const DefaultDoTPort = 853
+
+// dnsMessageType is the media type of DNS-over-HTTPS requests and responses.
+const dnsMessageType = "application/dns-message"
+
+// DNSTiming splits an encrypted DNS query into connection setup, which
+// covers the TCP connect and TLS handshake, and the query round trip.
+type DNSTiming struct {
+	Handshake time.Duration
+	Query     time.Duration
+}
+
+// encryptedDNSProbe sends the target's DNS query over TLS (dot) or HTTPS
+// (doh) to the target itself.
+type encryptedDNSProbe struct {
+	name    string
+	query   config.DNSProbe
+	timeout time.Duration
+	caFile  string
+}
+
+func newDoTProbe(cfg *config.Config) Probe {
+	return &encryptedDNSProbe{name: "dot", query: cfg.DNSProbe, timeout: cfg.DNSTimeout, caFile: cfg.TLSCAFile}
+}
+
+func newDoHProbe(cfg *config.Config) Probe {
+	return &encryptedDNSProbe{name: "doh", query: cfg.DNSProbe, timeout: cfg.DNSTimeout, caFile: cfg.TLSCAFile}
+}
+
+func (p *encryptedDNSProbe) Name() string {
+	return p.name
+}
+
+// Execute reports the query round trip as the probe latency and the
+// connection setup separately as handshake_ms.
+func (p *encryptedDNSProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	query := targetDNSQuery(p.query, target)
+	if query.Name == "" {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, errors.New("no dns_probe configured")))
+	}
+	roots, err := targetRootCAs(target, p.caFile)
+	if err != nil {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+	}
+	opts := TLSOptions{ServerName: target.SNI, RootCAs: roots}
+
+	var answer *DNSAnswer
+	var timing DNSTiming
+	if p.name == "dot" {
+		if opts.ServerName == "" {
+			opts.ServerName = target.Host
+		}
+		port := target.Port
+		if port <= 0 {
+			port = DefaultDoTPort
+		}
+		server := net.JoinHostPort(target.Host, strconv.Itoa(port))
+		answer, timing, err = QueryDoT(ctx, server, query, opts, target.timeout(p.timeout))
+	} else {
+		answer, timing, err = QueryDoH(ctx, dohURL(target, query), query, opts, target.timeout(p.timeout))
+	}
+	if err == nil {
+		err = answer.expect(query.Expect)
+	}
+
+	result := newProbeResult(timing.Query, err)
+	result.Details = answer.details(query)
+	result.Details["handshake_ms"] = durationMs(timing.Handshake)
+	result.Details["query_ms"] = durationMs(timing.Query)
+	return result
+}
+
+// dohURL returns the query's url, or https://host[:port]/dns-query for the
+// target.
+func dohURL(target Target, query config.DNSProbe) string {
+	if query.URL != "" {
+		return query.URL
+	}
+	host := target.Host
+	if target.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(target.Port))
+	}
+	return "https://" + host + "/dns-query"
+}
+
+// QueryDoT sends query to server (host:port) over TLS. The certificate is
+// verified against opts.RootCAs for opts.ServerName. The returned answer is
+// never nil.
+func QueryDoT(ctx context.Context, server string, query config.DNSProbe, opts TLSOptions, timeout time.Duration) (*DNSAnswer, DNSTiming, error) {
+	var timing DNSTiming
+	answer := &DNSAnswer{Server: server}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	qtype, ok := dnsTypes[dnsType(query)]
+	if !ok {
+		return answer, timing, newProbeError(ErrorClassConfig, fmt.Errorf("unsupported record type %q", query.Type))
+	}
+	name := queryName(query.Name, qtype)
+	id, err := dnsID()
+	if err != nil {
+		return answer, timing, err
+	}
+	packed, err := packDNSQuery(name, qtype, id)
+	if err != nil {
+		return answer, timing, err
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName: opts.ServerName,
+		RootCAs:    opts.RootCAs,
+		NextProtos: []string{"dot"},
+	}}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	timing.Handshake = time.Since(start)
+	if err != nil {
+		return answer, timing, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	start = time.Now()
+	resp, err := roundTripStream(ctx, conn, packed, id)
+	timing.Query = time.Since(start)
+	if err != nil {
+		return answer, timing, err
+	}
+	return answer, timing, answer.fill(resp, name, qtype)
+}
+
+// QueryDoH posts query to endpoint as an RFC 8484 DNS message. A fresh
+// connection is used for every query so that the handshake is measured.
+// The returned answer is never nil.
+func QueryDoH(ctx context.Context, endpoint string, query config.DNSProbe, opts TLSOptions, timeout time.Duration) (*DNSAnswer, DNSTiming, error) {
+	var timing DNSTiming
+	answer := &DNSAnswer{Server: endpoint}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	qtype, ok := dnsTypes[dnsType(query)]
+	if !ok {
+		return answer, timing, newProbeError(ErrorClassConfig, fmt.Errorf("unsupported record type %q", query.Type))
+	}
+	// RFC 8484 recommends ID 0 to keep responses cacheable.
+	name := queryName(query.Name, qtype)
+	packed, err := packDNSQuery(name, qtype, 0)
+	if err != nil {
+		return answer, timing, err
+	}
+
+	var connected time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodPost, endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return answer, timing, newProbeError(ErrorClassConfig, err)
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+
+	transport := &http.Transport{
+		TLSClientConfig:   &tls.Config{ServerName: opts.ServerName, RootCAs: opts.RootCAs},
+		ForceAttemptHTTP2: true,
+	}
+	defer transport.CloseIdleConnections()
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if !connected.IsZero() {
+		timing.Handshake = connected.Sub(start)
+	}
+	if err != nil {
+		return answer, timing, dohError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	timing.Query = time.Since(connected)
+	if err != nil {
+		return answer, timing, dnsIOError(ctx, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return answer, timing, newProbeError(ErrorClassHTTPStatus, fmt.Errorf("DoH server returned %s", resp.Status))
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != dnsMessageType {
+		return answer, timing, newProbeError(ErrorClassDNSFailure, fmt.Errorf("DoH server returned %q instead of %s", mediaType, dnsMessageType))
+	}
+
+	msg, err := parseDNSResponse(body, 0)
+	if err != nil {
+		return answer, timing, err
+	}
+	return answer, timing, answer.fill(msg, name, qtype)
+}
+
+// dohError reports deadline errors as DNS timeouts while keeping TLS and
+// connection errors from the HTTP client classifiable.
+func dohError(ctx context.Context, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && ctx.Err() == nil && !urlErr.Timeout() {
+		return urlErr.Err
+	}
+	return dnsIOError(ctx, err)
+}
\ No newline at end of file
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// newDoTServer serves z over TLS with the httptest certificate and returns
+// its target and a CA bundle for it.
+func newDoTServer(t *testing.T, z zone) (config.HostPort, string) {
+	// httptest servers share one certificate, so the bundle written by
+	// newTLSTarget also trusts this listener.
+	_, caFile := newTLSTarget(t)
+	stub := httptest.NewTLSServer(http.NotFoundHandler())
+	certs := stub.TLS.Certificates
+	stub.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					resp := z.answer(query)
+					binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+					conn.Write(append(length[:], resp...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}, caFile
+}
+
+// newDoHServer serves z at /dns-query over HTTPS with HTTP/2 enabled.
+func newDoHServer(t *testing.T, z zone) (config.HostPort, string) {
+	_, caFile := newTLSTarget(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dns-query", func(w http.ResponseWriter, r *http.Request) {
+		query, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dnsMessageType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", dnsMessageType)
+		w.Write(z.answer(query))
+	})
+	server := httptest.NewUnstartedServer(mux)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}, caFile
+}
+
+func TestEncryptedDNSProbes(t *testing.T) {
+	dot, caFile := newDoTServer(t, testZone())
+	doh, _ := newDoHServer(t, testZone())
+	cfg := &config.Config{DNSTimeout: 2 * time.Second, DNSProbe: config.DNSProbe{Name: "dc01.corp.local"}}
+
+	tests := []struct {
+		name   string
+		probe  Probe
+		target config.HostPort
+		caFile string
+		class  ErrorClass
+	}{
+		{"dot", newDoTProbe(cfg), dot, caFile, ""},
+		{"dot untrusted", newDoTProbe(cfg), dot, "", ErrorClassTLSHandshake},
+		{"dot NXDOMAIN", newDoTProbe(cfg), withDNS(dot, config.DNSProbe{Name: "missing.corp.local"}), caFile, ErrorClassDNSNXDomain},
+		{"doh", newDoHProbe(cfg), doh, caFile, ""},
+		{"doh untrusted", newDoHProbe(cfg), doh, "", ErrorClassTLSHandshake},
+		{"doh SRV", newDoHProbe(cfg), withDNS(doh, config.DNSProbe{Name: "_ldap._tcp.corp.local", Type: "SRV"}), caFile, ""},
+		{"doh wrong path", newDoHProbe(cfg), withDNS(doh, config.DNSProbe{URL: "https://" + net.JoinHostPort(doh.Host, strconv.Itoa(doh.Port)) + "/resolve"}), caFile, ErrorClassHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := tt.target
+			hp.CAFile = tt.caFile
+			result := tt.probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if tt.class == "" {
+				if result.Details["answer_count"] != 1 || result.Details["rcode"] != "NOERROR" {
+					t.Errorf("Expected one answer, got %v", result.Details)
+				}
+				if handshake, _ := result.Details["handshake_ms"].(float64); handshake <= 0 {
+					t.Errorf("Expected the handshake to be timed, got %v", result.Details)
+				}
+				if result.Details["query_ms"] != result.LatencyMs {
+					t.Errorf("Expected the probe latency to be the query time, got %v and %v", result.LatencyMs, result.Details["query_ms"])
+				}
+			}
+		})
+	}
+}
+
+func withDNS(hp config.HostPort, query config.DNSProbe) config.HostPort {
+	hp.DNS = query
+	return hp
+}
+
+func TestNexaInvalidDoHURL(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "dns.corp.local", Probes: []string{"doh"}, DNS: config.DNSProbe{Name: "corp.local", URL: "http://dns.corp.local/dns-query"}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for a DoH url without https")
+	}
+}
\ No newline at end of file
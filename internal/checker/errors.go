@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// ErrorClass is a coarse, stable classification of why a probe failed. It is
+// reported in results and used as a metric label.
+type ErrorClass string
+
+const (
+	ErrorClassDNSNXDomain     ErrorClass = "dns_nxdomain"
+	ErrorClassDNSTimeout      ErrorClass = "dns_timeout"
+	ErrorClassDNSFailure      ErrorClass = "dns_failure"
+	ErrorClassDNSMismatch     ErrorClass = "dns_mismatch"
+	ErrorClassDNSExternal     ErrorClass = "dns_external_view"
+	ErrorClassDNSHijacked     ErrorClass = "dns_hijacked"
+	ErrorClassConnRefused     ErrorClass = "conn_refused"
+	ErrorClassConnTimeout     ErrorClass = "conn_timeout"
+	ErrorClassConnReset       ErrorClass = "conn_reset"
+	ErrorClassNetUnreachable  ErrorClass = "net_unreachable"
+	ErrorClassHostUnreachable ErrorClass = "host_unreachable"
+	ErrorClassPortUnreachable ErrorClass = "port_unreachable"
+	ErrorClassNoReply         ErrorClass = "no_reply"
+	ErrorClassReplyMismatch   ErrorClass = "reply_mismatch"
+	ErrorClassTLSHandshake    ErrorClass = "tls_handshake"
+	ErrorClassTLSIntercepted  ErrorClass = "tls_intercepted"
+	ErrorClassSSHHandshake    ErrorClass = "ssh_handshake"
+	ErrorClassSSHHostKey      ErrorClass = "ssh_host_key_mismatch"
+	ErrorClassHTTPStatus      ErrorClass = "http_status"
+	ErrorClassHTTPAssertion   ErrorClass = "http_assertion"
+	ErrorClassCaptivePortal   ErrorClass = "captive_portal"
+	ErrorClassICMPPermission  ErrorClass = "icmp_permission_denied"
+	ErrorClassICMPNoReply     ErrorClass = "icmp_no_reply"
+	ErrorClassICMPLoss        ErrorClass = "icmp_packet_loss"
+	ErrorClassNTPUnsynced     ErrorClass = "ntp_unsynchronized"
+	ErrorClassClockOffset     ErrorClass = "clock_offset"
+	ErrorClassCancelled       ErrorClass = "cancelled"
+	ErrorClassConfig          ErrorClass = "config"
+	ErrorClassUnknown         ErrorClass = "unknown"
+)
+
+// ProbeError is an error that already carries its classification.
+type ProbeError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ProbeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+func newProbeError(class ErrorClass, err error) *ProbeError {
+	return &ProbeError{Class: class, Err: err}
+}
+
+// ClassifyError maps an error returned by a probe to an ErrorClass.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var probeErr *ProbeError
+	if errors.As(err, &probeErr) {
+		return probeErr.Class
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCancelled
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return ErrorClassDNSNXDomain
+		case dnsErr.IsTimeout:
+			return ErrorClassDNSTimeout
+		default:
+			return ErrorClassDNSFailure
+		}
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ErrorClassConnReset
+	case errors.Is(err, syscall.ENETUNREACH):
+		return ErrorClassNetUnreachable
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return ErrorClassHostUnreachable
+	}
+
+	if isTLSError(err) {
+		return ErrorClassTLSHandshake
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassConnTimeout
+	}
+
+	return ErrorClassUnknown
+}
+
+// isTLSError reports whether err comes from a TLS handshake or certificate
+// verification. Alerts sent or received by crypto/tls are net.OpErrors with
+// the "local error" and "remote error" operations.
+func isTLSError(err error) bool {
+	var (
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+		verifyErr   *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		certInvalid x509.CertificateInvalidError
+		opErr       *net.OpError
+	)
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &verifyErr) || errors.As(err, &unknownAuth) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &certInvalid) ||
+		(errors.As(err, &opErr) && (opErr.Op == "local error" || opErr.Op == "remote error"))
+}
+
+// isPermissionError reports whether err is an EPERM/EACCES from the kernel,
+// as returned when opening ICMP sockets without the required privileges.
+func isPermissionError(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) ||
+		errors.Is(err, os.ErrPermission)
+}
\ No newline at end of file
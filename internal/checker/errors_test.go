@@ -0,0 +1,44 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ""},
+		{"probe error", newProbeError(ErrorClassHTTPStatus, errors.New("503")), ErrorClassHTTPStatus},
+		{"nxdomain", &net.DNSError{Err: "no such host", IsNotFound: true}, ErrorClassDNSNXDomain},
+		{"dns timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, ErrorClassDNSTimeout},
+		{"dns failure", &net.DNSError{Err: "server misbehaving"}, ErrorClassDNSFailure},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ErrorClassConnRefused},
+		{"reset", fmt.Errorf("read: %w", syscall.ECONNRESET), ErrorClassConnReset},
+		{"net unreachable", &net.OpError{Op: "dial", Err: syscall.ENETUNREACH}, ErrorClassNetUnreachable},
+		{"deadline", context.DeadlineExceeded, ErrorClassConnTimeout},
+		{"i/o timeout", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, ErrorClassConnTimeout},
+		{"cancelled", fmt.Errorf("dial: %w", context.Canceled), ErrorClassCancelled},
+		{"tls alert", &net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}, ErrorClassTLSHandshake},
+		{"tls record", fmt.Errorf("handshake: %w", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), ErrorClassTLSHandshake},
+		{"tls in message only", errors.New("dial: tls: something odd"), ErrorClassUnknown},
+		{"unknown", errors.New("something odd"), ErrorClassUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassifyError(tc.err); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
\ No newline at end of file
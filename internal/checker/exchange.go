@@ -0,0 +1,105 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// maxReplyPreview bounds the reply reported in probe details.
+const maxReplyPreview = 128
+
+// exchangePayload returns the bytes to send for ex.
+func exchangePayload(ex config.Exchange) ([]byte, error) {
+	if ex.SendHex != "" {
+		return decodeHex(ex.SendHex)
+	}
+	return []byte(ex.Send), nil
+}
+
+// expectsReply reports whether ex places any requirement on the reply.
+func expectsReply(ex config.Exchange) bool {
+	return ex.Expect != "" || ex.ExpectHex != "" || ex.ExpectRegex != ""
+}
+
+// verifyReply checks reply against every expectation in ex.
+func verifyReply(reply []byte, ex config.Exchange) error {
+	if ex.Expect != "" && !bytes.Contains(reply, []byte(ex.Expect)) {
+		return replyMismatch("reply does not contain %q", ex.Expect)
+	}
+	if ex.ExpectHex != "" {
+		want, err := decodeHex(ex.ExpectHex)
+		if err != nil {
+			return newProbeError(ErrorClassConfig, err)
+		}
+		if !bytes.Contains(reply, want) {
+			return replyMismatch("reply does not contain %s", hex.EncodeToString(want))
+		}
+	}
+	if ex.ExpectRegex != "" {
+		re, err := regexp.Compile(ex.ExpectRegex)
+		if err != nil {
+			return newProbeError(ErrorClassConfig, err)
+		}
+		if !re.Match(reply) {
+			return replyMismatch("reply does not match %q", ex.ExpectRegex)
+		}
+	}
+	return nil
+}
+
+func replyMismatch(format string, args ...interface{}) error {
+	return newProbeError(ErrorClassReplyMismatch, fmt.Errorf(format, args...))
+}
+
+// replyPreview returns the start of reply as text when it is printable and
+// as hex otherwise.
+func replyPreview(reply []byte) string {
+	if len(reply) > maxReplyPreview {
+		reply = reply[:maxReplyPreview]
+	}
+	text := string(reply)
+	for _, r := range text {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return hex.EncodeToString(reply)
+		}
+	}
+	return text
+}
+
+// decodeHex decodes hex bytes, ignoring spaces, colons and a 0x prefix.
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	s = strings.NewReplacer(" ", "", ":", "").Replace(s)
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %v", s, err)
+	}
+	return b, nil
+}
+
+func validateExchange(ex config.Exchange) error {
+	if ex.Send != "" && ex.SendHex != "" {
+		return errors.New("set only one of send and send_hex")
+	}
+	for _, h := range []string{ex.SendHex, ex.ExpectHex} {
+		if h == "" {
+			continue
+		}
+		if _, err := decodeHex(h); err != nil {
+			return err
+		}
+	}
+	if ex.ExpectRegex != "" {
+		if _, err := regexp.Compile(ex.ExpectRegex); err != nil {
+			return fmt.Errorf("invalid expect_regex: %v", err)
+		}
+	}
+	return nil
+}
\ No newline at end of file
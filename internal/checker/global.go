@@ -0,0 +1,133 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/pkg/utils"
+)
+
+// CheckTypeGlobal marks run-level checks, which execute once per run instead
+// of once per host and are consumed by groups through global_checks.
+const CheckTypeGlobal CheckType = "global"
+
+// runLevelProbes are the probes that can run without a host.
+var runLevelProbes = []string{"http", "dns", "captive_portal", "split_horizon", "dns_integrity"}
+
+// globalProbes returns the names of the run-level probes. Unless configured
+// explicitly, http runs when http_url is set, dns when dns_probe is set,
+// split_horizon when split_horizon.name is set and dns_integrity when it is
+// enabled. With captive-portal detection enabled, captive_portal replaces
+// http.
+func globalProbes(cfg *config.Config) []string {
+	if cfg.GlobalProbes != nil {
+		return cfg.GlobalProbes
+	}
+
+	var names []string
+	switch {
+	case cfg.CaptivePortal.Enabled && len(captiveURLs(cfg)) > 0:
+		names = append(names, "captive_portal")
+	case cfg.HTTPURL != "":
+		names = append(names, "http")
+	}
+	if cfg.DNSProbe.Name != "" {
+		names = append(names, "dns")
+	}
+	if cfg.SplitHorizon.Name != "" {
+		names = append(names, "split_horizon")
+	}
+	if cfg.DNSIntegrity.Enabled {
+		names = append(names, "dns_integrity")
+	}
+	return names
+}
+
+func globalTargets(cfg *config.Config) []Target {
+	var targets []Target
+	for _, name := range globalProbes(cfg) {
+		targets = append(targets, Target{
+			HostPort: config.HostPort{Probes: []string{name}},
+			Type:     CheckTypeGlobal,
+		})
+	}
+	return targets
+}
+
+// metricTarget returns the target label for metrics. Run-level checks have no
+// host and are labelled "global:<probe>".
+func metricTarget(target Target) string {
+	if target.Type == CheckTypeGlobal && len(target.Probes) == 1 {
+		return string(CheckTypeGlobal) + ":" + target.Probes[0]
+	}
+	return target.String()
+}
+
+// groupGlobalChecks returns the run-level checks a group takes into account.
+// The external group uses captive_portal or http and the corporate group uses
+// dns and split_horizon by default.
+func groupGlobalChecks(cfg *config.Config, group config.Group) []string {
+	if group.GlobalChecks != nil {
+		return group.GlobalChecks
+	}
+
+	var defaults []string
+	switch CheckType(group.Name) {
+	case CheckTypeExternal:
+		defaults = []string{"captive_portal", "http"}
+	case CheckTypeCorporate:
+		defaults = []string{"dns", "split_horizon"}
+	}
+
+	var names []string
+	for _, name := range defaults {
+		if utils.ContainsString(globalProbes(cfg), name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func validateGlobalChecks(cfg *config.Config, probes map[string]Probe) error {
+	global := globalProbes(cfg)
+	seen := make(map[string]bool)
+	for _, name := range global {
+		if _, ok := probes[name]; !ok {
+			return fmt.Errorf("unknown global probe %q", name)
+		}
+		if !utils.ContainsString(runLevelProbes, name) {
+			return fmt.Errorf("global probe %q needs a host", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("global probe %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	for _, group := range configGroups(cfg) {
+		for _, name := range group.GlobalChecks {
+			if !utils.ContainsString(global, name) {
+				return fmt.Errorf("group %q uses global check %q, which is not in global_probes", group.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// policyInputs merges the target results of a group with the run-level
+// checks it consumes, keyed as "global:<probe>".
+func policyInputs(group *GroupResult, global map[string]CheckResult) map[string]CheckResult {
+	if len(group.GlobalChecks) == 0 {
+		return group.Details
+	}
+
+	inputs := make(map[string]CheckResult, len(group.Details)+len(group.GlobalChecks))
+	for key, check := range group.Details {
+		inputs[key] = check
+	}
+	for _, name := range group.GlobalChecks {
+		if check, ok := global[name]; ok {
+			inputs[string(CheckTypeGlobal)+":"+name] = check
+		}
+	}
+	return inputs
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package checker
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// DefaultGroupExitCode is OR-ed into the exit code when an additional group
+// without an explicit exit_code is down.
+const DefaultGroupExitCode = 4
+
+// GroupResult is the status of one named group of targets.
+type GroupResult struct {
+	OK           bool                   `json:"ok"`
+	Policy       string                 `json:"policy"`
+	PassedWeight int                    `json:"passed_weight"`
+	TotalWeight  int                    `json:"total_weight"`
+	ExitCode     int                    `json:"exit_code"`
+	GlobalChecks []string               `json:"global_checks,omitempty"`
+	Details      map[string]CheckResult `json:"details"`
+}
+
+func isBuiltinGroup(name string) bool {
+	return name == string(CheckTypeExternal) || name == string(CheckTypeCorporate)
+}
+
+// configGroups returns the built-in external and corporate groups followed
+// by the additional groups from the config, in declaration order.
+func configGroups(cfg *config.Config) []config.Group {
+	groups := []config.Group{
+		{
+			Name:     string(CheckTypeExternal),
+			Hosts:    append([]config.HostPort{}, cfg.ExternalHosts...),
+			ExitCode: 1,
+		},
+		{
+			Name:     string(CheckTypeCorporate),
+			Hosts:    append([]config.HostPort{}, cfg.CorpHosts...),
+			ExitCode: 2,
+		},
+	}
+
+	for _, group := range cfg.Groups {
+		if isBuiltinGroup(group.Name) {
+			for i := range groups {
+				if groups[i].Name == group.Name {
+					groups[i].Hosts = append(groups[i].Hosts, group.Hosts...)
+					groups[i].Probes = group.Probes
+					groups[i].Policy = group.Policy
+					groups[i].GlobalChecks = group.GlobalChecks
+				}
+			}
+			continue
+		}
+		if group.ExitCode == 0 {
+			group.ExitCode = DefaultGroupExitCode
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func validateGroups(cfg *config.Config) error {
+	seen := make(map[string]bool)
+	codes := make(map[int]string)
+	for _, group := range cfg.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("group without a name")
+		}
+		if group.Name == string(CheckTypeGlobal) {
+			return fmt.Errorf("group name %q is reserved for run-level checks", group.Name)
+		}
+		if seen[group.Name] {
+			return fmt.Errorf("group %q defined twice", group.Name)
+		}
+		seen[group.Name] = true
+
+		if isBuiltinGroup(group.Name) {
+			continue
+		}
+		code := group.ExitCode
+		if code == 0 {
+			code = DefaultGroupExitCode
+		}
+		if code < 1 || code > 255 {
+			return fmt.Errorf("group %q: exit_code %d out of range 1-255", group.Name, code)
+		}
+		if code == 1 || code == 2 {
+			return fmt.Errorf("group %q: exit_code %d is reserved for the %s group", group.Name, code, builtinGroupFor(code))
+		}
+		if other, ok := codes[code]; ok {
+			log.Printf("Groups %q and %q share exit code %d and cannot be told apart", other, group.Name, code)
+			continue
+		}
+		codes[code] = group.Name
+	}
+	return nil
+}
+
+func builtinGroupFor(code int) CheckType {
+	if code == 1 {
+		return CheckTypeExternal
+	}
+	return CheckTypeCorporate
+}
+
+// groupPolicies parses the policy of every group.
+func groupPolicies(groups []config.Group) (map[string]Policy, error) {
+	policies := make(map[string]Policy, len(groups))
+	for _, group := range groups {
+		policy, err := ParsePolicy(group.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("group %q: %v", group.Name, err)
+		}
+		policies[group.Name] = policy
+	}
+	return policies, nil
+}
\ No newline at end of file
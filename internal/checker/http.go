@@ -1,31 +1,319 @@
 package checker
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptrace"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ferchd/nexa/internal/config"
 )
 
-func CheckHTTP(url string, timeout time.Duration) bool {
-	client := &http.Client{
+func init() {
+	RegisterProbe("http", newHTTPProbe)
+}
+
+// maxHTTPBody bounds how much of a response body is read for assertions.
+const maxHTTPBody = 1 << 20
+
+type httpProbe struct {
+	url     string
+	timeout time.Duration
+	caFile  string
+}
+
+func newHTTPProbe(cfg *config.Config) Probe {
+	return &httpProbe{url: cfg.HTTPURL, timeout: cfg.HTTPTimeout, caFile: cfg.TLSCAFile}
+}
+
+func (p *httpProbe) Name() string {
+	return "http"
+}
+
+func (p *httpProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	url := target.HTTPURL
+	if url == "" {
+		url = p.url
+	}
+	if url == "" {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, errors.New("no http_url configured")))
+	}
+
+	client := newHTTPClient(target.timeout(p.timeout), target.HTTP.MaxRedirects)
+	seen := &pinCheck{}
+	if pins := pinsFor(target); pins.configured() {
+		roots, err := targetRootCAs(target, p.caFile)
+		if err != nil {
+			return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+		}
+		client.Transport = pinnedTransport(pins, urlHostname(url), roots, seen)
+	}
+
+	exchange, err := doHTTP(ctx, client, url, target.HTTP)
+	if err == nil {
+		err = exchange.verify(target.HTTP)
+	}
+
+	result := newProbeResult(exchange.TTFB, err)
+	result.Details = map[string]interface{}{"http_url": url}
+	if exchange.Status > 0 {
+		result.Details["status"] = exchange.Status
+	}
+	for key, value := range seen.details() {
+		result.Details[key] = value
+	}
+	return result
+}
+
+// urlHostname returns the host name of rawURL without its port.
+func urlHostname(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// CheckHTTP requests url and returns the time to the first response byte.
+func CheckHTTP(ctx context.Context, url string, timeout time.Duration) (time.Duration, error) {
+	exchange, err := doHTTP(ctx, newHTTPClient(timeout, 0), url, config.HTTPOptions{})
+	if err != nil {
+		return exchange.TTFB, err
+	}
+	return exchange.TTFB, exchange.verify(config.HTTPOptions{})
+}
+
+// httpExchange is a completed request: the response status, headers and
+// body, plus the time to the first response byte.
+type httpExchange struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	TTFB   time.Duration
+}
+
+// doHTTP sends the request described by opts to url. The returned exchange
+// is never nil; on error only TTFB is set, to the time until the failure.
+func doHTTP(ctx context.Context, client *http.Client, url string, opts config.HTTPOptions) (*httpExchange, error) {
+	exchange := &httpExchange{}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if opts.Body != "" {
+		body = strings.NewReader(opts.Body)
+	}
+
+	req, err := newHTTPRequest(ctx, strings.ToUpper(method), url, body)
+	if err != nil {
+		return exchange, newProbeError(ErrorClassConfig, err)
+	}
+	for name, value := range opts.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	switch {
+	case opts.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+os.ExpandEnv(opts.BearerToken))
+	case opts.Username != "":
+		req.SetBasicAuth(opts.Username, os.ExpandEnv(opts.Password))
+	}
+
+	resp, ttfb, err := sendHTTP(client, req)
+	exchange.TTFB = ttfb
+	if err != nil {
+		return exchange, err
+	}
+	defer resp.Body.Close()
+
+	exchange.Status = resp.StatusCode
+	exchange.Header = resp.Header
+	exchange.Body, err = io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	return exchange, err
+}
+
+// verify checks the response against the expected status codes and the
+// body and header assertions of opts.
+func (x *httpExchange) verify(opts config.HTTPOptions) error {
+	if !statusExpected(x.Status, opts.ExpectStatus) {
+		return newProbeError(ErrorClassHTTPStatus, fmt.Errorf("unexpected status %d %s", x.Status, http.StatusText(x.Status)))
+	}
+
+	for _, want := range opts.BodyContains {
+		if !strings.Contains(string(x.Body), want) {
+			return assertionError("body does not contain %q", want)
+		}
+	}
+	for _, pattern := range opts.BodyMatches {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return newProbeError(ErrorClassConfig, err)
+		}
+		if !re.Match(x.Body) {
+			return assertionError("body does not match %q", pattern)
+		}
+	}
+	for name, pattern := range opts.HeaderMatches {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return newProbeError(ErrorClassConfig, err)
+		}
+		if value := x.Header.Get(name); !re.MatchString(value) {
+			return assertionError("header %s %q does not match %q", name, value, pattern)
+		}
+	}
+
+	if len(opts.JSON) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(x.Body, &doc); err != nil {
+		return assertionError("body is not JSON: %v", err)
+	}
+	for _, assertion := range opts.JSON {
+		value, ok := jsonPath(doc, assertion.Path)
+		if !ok {
+			return assertionError("json %s not found", assertion.Path)
+		}
+		if got := jsonString(value); got != assertion.Equals {
+			return assertionError("json %s = %q, want %q", assertion.Path, got, assertion.Equals)
+		}
+	}
+	return nil
+}
+
+func assertionError(format string, args ...interface{}) error {
+	return newProbeError(ErrorClassHTTPAssertion, fmt.Errorf(format, args...))
+}
+
+// statusExpected reports whether status is in expected, or is 2xx/3xx when
+// expected is empty.
+func statusExpected(status int, expected []int) bool {
+	if len(expected) == 0 {
+		return status >= 200 && status < 400
+	}
+	for _, code := range expected {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPath walks a dotted path such as "checks.0.status" or
+// "$.checks[0].status" through a decoded JSON document.
+func jsonPath(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	if path == "" {
+		return doc, true
+	}
+
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			value = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// jsonString formats a JSON value for comparison: strings as is, everything
+// else in its JSON encoding.
+func jsonString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// validateHTTPOptions rejects assertions that can never be evaluated.
+func validateHTTPOptions(host string, opts config.HTTPOptions) error {
+	patterns := append([]string(nil), opts.BodyMatches...)
+	for _, pattern := range opts.HeaderMatches {
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("host %s: invalid http pattern %q: %v", host, pattern, err)
+		}
+	}
+	if opts.MaxRedirects < 0 {
+		return fmt.Errorf("host %s: max_redirects must not be negative", host)
+	}
+	return nil
+}
+
+// newHTTPClient returns a client that follows at most maxRedirects
+// redirects. With maxRedirects 0 the redirect response itself is returned.
+func newHTTPClient(timeout time.Duration, maxRedirects int) *http.Client {
+	return &http.Client{
 		Timeout: timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
+			if maxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return newProbeError(ErrorClassHTTPStatus, fmt.Errorf("stopped after %d redirects", maxRedirects))
+			}
+			return nil
 		},
 	}
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+func newHTTPRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
 	req.Header.Set("User-Agent", "Nexa/1.0")
 	req.Header.Set("Accept", "*/*")
+	return req, nil
+}
 
+// sendHTTP performs req and returns the response together with the time to
+// the first response byte. On error the elapsed time is returned instead.
+func sendHTTP(client *http.Client, req *http.Request) (*http.Response, time.Duration, error) {
+	var firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		return false
+		return nil, time.Since(start), err
 	}
-	defer resp.Body.Close()
 
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
+	ttfb := firstByte.Sub(start)
+	if firstByte.IsZero() {
+		ttfb = time.Since(start)
+	}
+	return resp, ttfb, nil
 }
\ No newline at end of file
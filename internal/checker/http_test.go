@@ -0,0 +1,108 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func newHealthServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"degraded","checks":[{"name":"db","ok":true}]}`)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "nexa" || pass != "secret" {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("X-Request"), body)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/health", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPProbe_Options(t *testing.T) {
+	server := newHealthServer(t)
+	probe := newHTTPProbe(&config.Config{HTTPTimeout: 2 * time.Second})
+
+	tests := []struct {
+		name  string
+		path  string
+		opts  config.HTTPOptions
+		class ErrorClass
+	}{
+		{"default status range", "/health", config.HTTPOptions{}, ""},
+		{"json equality", "/health", config.HTTPOptions{JSON: []config.JSONAssertion{{Path: "checks[0].ok", Equals: "true"}}}, ""},
+		{"degraded json", "/health", config.HTTPOptions{JSON: []config.JSONAssertion{{Path: "$.status", Equals: "ok"}}}, ErrorClassHTTPAssertion},
+		{"missing json path", "/health", config.HTTPOptions{JSON: []config.JSONAssertion{{Path: "checks.3.name", Equals: "db"}}}, ErrorClassHTTPAssertion},
+		{"body substring", "/health", config.HTTPOptions{BodyContains: []string{`"name":"db"`}}, ""},
+		{"body regex", "/health", config.HTTPOptions{BodyMatches: []string{`"status":"(ok|up)"`}}, ErrorClassHTTPAssertion},
+		{"header match", "/health", config.HTTPOptions{HeaderMatches: map[string]string{"content-type": "^application/json"}}, ""},
+		{"unexpected status", "/health", config.HTTPOptions{ExpectStatus: []int{204}}, ErrorClassHTTPStatus},
+		{"redirect not followed", "/redirect", config.HTTPOptions{ExpectStatus: []int{200}}, ErrorClassHTTPStatus},
+		{"redirect followed", "/redirect", config.HTTPOptions{ExpectStatus: []int{200}, MaxRedirects: 1}, ""},
+		{"basic auth", "/echo", config.HTTPOptions{
+			Method:        "post",
+			Headers:       map[string]string{"x-request": "probe"},
+			Body:          "payload",
+			Username:      "nexa",
+			Password:      "secret",
+			ExpectStatus:  []int{201},
+			BodyContains:  []string{"POST probe payload"},
+			HeaderMatches: map[string]string{"X-Method": "POST"},
+		}, ""},
+		{"bearer auth", "/echo", config.HTTPOptions{BearerToken: "token", ExpectStatus: []int{201}}, ""},
+		{"missing auth", "/echo", config.HTTPOptions{ExpectStatus: []int{201}}, ErrorClassHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := Target{HostPort: config.HostPort{HTTPURL: server.URL + tt.path, HTTP: tt.opts}}
+			result := probe.Execute(context.Background(), target)
+			if result.ErrorClass != tt.class {
+				t.Errorf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+		})
+	}
+}
+
+func TestHTTPProbe_RedirectLimit(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+
+	probe := newHTTPProbe(&config.Config{HTTPTimeout: 2 * time.Second})
+	target := Target{HostPort: config.HostPort{HTTPURL: server.URL + "/", HTTP: config.HTTPOptions{MaxRedirects: 3}}}
+	result := probe.Execute(context.Background(), target)
+	if result.Success || result.ErrorClass != ErrorClassHTTPStatus {
+		t.Errorf("Expected the redirect limit to fail with %s, got %+v", ErrorClassHTTPStatus, result)
+	}
+}
+
+func TestNexaInvalidHTTPPattern(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{{Host: "example.com", HTTPURL: "http://example.com", HTTP: config.HTTPOptions{BodyMatches: []string{"("}}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an invalid body pattern")
+	}
+}
\ No newline at end of file
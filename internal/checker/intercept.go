@@ -0,0 +1,217 @@
+package checker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// certPins is the certificate identity expected from a target. Pins match
+// any certificate of a verified chain, so a leaf, intermediate or root can
+// be pinned. Presented certificates outside the verified chains are
+// ignored: an intercepting proxy could append the genuine pinned CA to its
+// forged chain.
+type certPins struct {
+	spki   [][]byte
+	certs  [][]byte
+	issuer string
+	errs   []string
+}
+
+func pinsFor(target Target) certPins {
+	pins := certPins{issuer: target.ExpectIssuer}
+	for _, pin := range target.SPKIPins {
+		if sum, err := decodeFingerprint(pin); err == nil {
+			pins.spki = append(pins.spki, sum)
+		} else {
+			pins.errs = append(pins.errs, err.Error())
+		}
+	}
+	for _, pin := range target.CertFingerprints {
+		if sum, err := decodeFingerprint(pin); err == nil {
+			pins.certs = append(pins.certs, sum)
+		} else {
+			pins.errs = append(pins.errs, err.Error())
+		}
+	}
+	return pins
+}
+
+func (p certPins) configured() bool {
+	return len(p.spki) > 0 || len(p.certs) > 0 || p.issuer != ""
+}
+
+// check returns an error naming the observed issuer when the leaf of the
+// verified chains does not have the expected issuer, or no verified chain
+// contains an expected fingerprint.
+func (p certPins) check(verified [][]*x509.Certificate) error {
+	if len(verified) == 0 || len(verified[0]) == 0 {
+		return errors.New("TLS intercepted: no verified chain")
+	}
+	leaf := verified[0][0]
+	if p.issuer != "" && !issuerMatches(leaf.Issuer, p.issuer) {
+		return fmt.Errorf("TLS intercepted: issuer %q does not match expected %q", leaf.Issuer.String(), p.issuer)
+	}
+	if len(p.spki) == 0 && len(p.certs) == 0 {
+		return nil
+	}
+
+	for _, verifiedChain := range verified {
+		for _, cert := range verifiedChain {
+			spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			der := sha256.Sum256(cert.Raw)
+			if containsSum(p.spki, spki[:]) || containsSum(p.certs, der[:]) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("TLS intercepted: no pinned fingerprint in verified chain issued by %q", leaf.Issuer.String())
+}
+
+// pinCheck records what the pinned transport of an http probe saw.
+type pinCheck struct {
+	mu          sync.Mutex
+	checked     bool
+	intercepted bool
+	issuer      string
+}
+
+func (c *pinCheck) record(issuer string, intercepted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked = true
+	c.issuer = issuer
+	c.intercepted = c.intercepted || intercepted
+}
+
+// details reports the outcome like the tls probe does, or nil when no
+// pinned connection was made.
+func (c *pinCheck) details() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checked {
+		return nil
+	}
+	return map[string]interface{}{"tls_intercepted": c.intercepted, "issuer": c.issuer}
+}
+
+// pinnedTransport returns a transport that checks pins against the
+// verified chains of TLS connections to host. Connections whose
+// certificate is not valid for host, such as redirects to other sites, are
+// not pinned.
+func pinnedTransport(pins certPins, host string, roots *x509.CertPool, seen *pinCheck) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs: roots,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			leaf := state.PeerCertificates[0]
+			if leaf.VerifyHostname(host) != nil {
+				return nil
+			}
+			err := pins.check(state.VerifiedChains)
+			seen.record(leaf.Issuer.String(), err != nil)
+			if err != nil {
+				return newProbeError(ErrorClassTLSIntercepted, err)
+			}
+			return nil
+		},
+	}
+	return transport
+}
+
+// issuerMatches reports whether want is the issuer's common name or its
+// full distinguished name, such as "CN=WR2,O=Google Trust Services,C=US".
+func issuerMatches(issuer pkix.Name, want string) bool {
+	return want == issuer.CommonName || want == issuer.String()
+}
+
+func containsSum(sums [][]byte, sum []byte) bool {
+	for _, s := range sums {
+		if bytes.Equal(s, sum) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeFingerprint accepts a SHA-256 fingerprint as hex, optionally
+// colon-separated, or as base64 with an optional "sha256/" prefix.
+func decodeFingerprint(pin string) ([]byte, error) {
+	pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+	if sum, err := hex.DecodeString(strings.ReplaceAll(pin, ":", "")); err == nil && len(sum) == sha256.Size {
+		return sum, nil
+	}
+	if sum, err := base64.StdEncoding.DecodeString(pin); err == nil && len(sum) == sha256.Size {
+		return sum, nil
+	}
+	return nil, fmt.Errorf("invalid SHA-256 fingerprint %q", pin)
+}
+
+// spkiFingerprint returns the base64 SHA-256 of the certificate's public
+// key, in the format used by HTTP public key pinning.
+func spkiFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// certFingerprint returns the hex SHA-256 of the DER certificate.
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// validatePins rejects fingerprints that cannot be decoded, and pins on
+// targets without a probe that checks them.
+func validatePins(target Target) error {
+	pins := pinsFor(target)
+	if len(pins.errs) > 0 {
+		return fmt.Errorf("host %s: %s", target.Host, pins.errs[0])
+	}
+	if !pins.configured() {
+		return nil
+	}
+	for _, name := range targetProbes(target) {
+		if name == "tls" || name == "http" {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s: spki_pins, cert_fingerprints and expect_issuer need the tls or http probe", target.Host)
+}
+
+// tlsInterception reports whether any tls or http probe of the run saw an
+// unexpected certificate, and the issuer it observed.
+func tlsInterception(result *GlobalResult) (bool, string) {
+	groups := []map[string]CheckResult{result.Global, result.InternetDetails, result.CorporateDetails}
+	for _, name := range result.extraGroups() {
+		groups = append(groups, result.Groups[name].Details)
+	}
+
+	for _, checks := range groups {
+		keys := make([]string, 0, len(checks))
+		for key := range checks {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			for _, probe := range []string{"tls", "http"} {
+				details := checks[key].Probes[probe].Details
+				if intercepted, _ := details["tls_intercepted"].(bool); intercepted {
+					issuer, _ := details["issuer"].(string)
+					return true, issuer
+				}
+			}
+		}
+	}
+	return false, ""
+}
\ No newline at end of file
@@ -1,10 +1,16 @@
 package checker
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
 )
 
 func MockTCPServer(t *testing.T) (string, func()) {
@@ -40,4 +46,90 @@ func MockHTTPServer(t *testing.T, statusCode int) (*httptest.Server, string) {
 	}))
 	
 	return server, server.URL
+}
+
+// mockProbe is a registrable probe with a fixed outcome.
+type mockProbe struct {
+	name     string
+	success  bool
+	degraded bool
+}
+
+func (p *mockProbe) Name() string {
+	return p.name
+}
+
+func (p *mockProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if !p.success {
+		return newProbeResult(0, newProbeError(ErrorClassConnRefused, errors.New("mock failure")))
+	}
+	result := ProbeResult{Success: true, Degraded: p.degraded}
+	if p.degraded {
+		result.reason = "12.5% packet loss"
+	}
+	return result
+}
+
+// countingProbe fails every attempt and records how often it ran.
+type countingProbe struct {
+	calls int32
+}
+
+func (p *countingProbe) Name() string {
+	return "mock-count"
+}
+
+func (p *countingProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	atomic.AddInt32(&p.calls, 1)
+	return ProbeResult{}
+}
+
+var mockCounter = &countingProbe{}
+
+// concurrencyProbe holds each execution briefly and records the highest
+// number of executions that overlapped.
+type concurrencyProbe struct {
+	current int32
+	max     int32
+}
+
+func (p *concurrencyProbe) Name() string {
+	return "mock-slow"
+}
+
+func (p *concurrencyProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	n := atomic.AddInt32(&p.current, 1)
+	defer atomic.AddInt32(&p.current, -1)
+	for {
+		old := atomic.LoadInt32(&p.max)
+		if n <= old || atomic.CompareAndSwapInt32(&p.max, old, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return ProbeResult{Success: true}
+}
+
+func (p *concurrencyProbe) reset() {
+	atomic.StoreInt32(&p.max, 0)
+}
+
+var mockSlow = &concurrencyProbe{}
+
+func init() {
+	RegisterProbe("mock-count", func(cfg *config.Config) Probe {
+		return mockCounter
+	})
+	RegisterProbe("mock-slow", func(cfg *config.Config) Probe {
+		return mockSlow
+	})
+	RegisterProbe("mock-up", func(cfg *config.Config) Probe {
+		return &mockProbe{name: "mock-up", success: true}
+	})
+	RegisterProbe("mock-down", func(cfg *config.Config) Probe {
+		return &mockProbe{name: "mock-down", success: false}
+	})
+	RegisterProbe("mock-degraded", func(cfg *config.Config) Probe {
+		return &mockProbe{name: "mock-degraded", success: true, degraded: true}
+	})
 }
\ No newline at end of file
@@ -0,0 +1,192 @@
+package checker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/internal/metrics"
+)
+
+func init() {
+	RegisterProbe("ntp", newNTPProbe)
+}
+
+// DefaultNTPPort is used by the ntp probe for targets without a port.
+const DefaultNTPPort = 123
+
+// ntpPacketSize is the size of an SNTP header without extensions.
+const ntpPacketSize = 48
+
+// ntpEpoch is the start of NTP era 0.
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NTPResponse is the outcome of one SNTP exchange. Offset is how far the
+// server's clock is ahead of the local one and Delay the round trip minus
+// the server's processing time.
+type NTPResponse struct {
+	Stratum     int
+	ReferenceID string
+	Offset      time.Duration
+	Delay       time.Duration
+}
+
+func (r *NTPResponse) details() map[string]interface{} {
+	return map[string]interface{}{
+		"stratum":      r.Stratum,
+		"reference_id": r.ReferenceID,
+		"offset_ms":    durationMs(r.Offset),
+		"delay_ms":     durationMs(r.Delay),
+	}
+}
+
+type ntpProbe struct {
+	timeout time.Duration
+	opts    config.NTPOptions
+}
+
+func newNTPProbe(cfg *config.Config) Probe {
+	return &ntpProbe{timeout: cfg.UDPTimeout, opts: cfg.NTP}
+}
+
+func (p *ntpProbe) Name() string {
+	return "ntp"
+}
+
+// Execute reports the round-trip delay as the probe latency. The offset
+// is checked against the thresholds in either direction.
+func (p *ntpProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	opts := targetNTPOptions(p.opts, target)
+	port := target.Port
+	if port <= 0 {
+		port = DefaultNTPPort
+	}
+
+	resp, err := QueryNTP(ctx, target.Host, port, target.timeout(p.timeout))
+	if err != nil {
+		return newProbeResult(0, err)
+	}
+
+	offset := resp.Offset
+	if offset < 0 {
+		offset = -offset
+	}
+	if opts.MaxOffset > 0 && offset > opts.MaxOffset {
+		err = newProbeError(ErrorClassClockOffset,
+			fmt.Errorf("clock offset %s to %s exceeds %s", resp.Offset.Round(time.Millisecond), target.Host, opts.MaxOffset))
+	}
+
+	result := newProbeResult(resp.Delay, err)
+	result.Degraded = result.Success && opts.DegradedOffset > 0 && offset > opts.DegradedOffset
+	result.Details = resp.details()
+	result.record = func(m *metrics.PrometheusMetrics, target string) {
+		m.UpdateNTPOffset(target, resp.Offset.Seconds())
+	}
+	if result.Degraded {
+		result.reason = fmt.Sprintf("clock offset %.0fms", durationMs(resp.Offset))
+	}
+	return result
+}
+
+// QueryNTP sends an SNTP client request to host:port. The transmit
+// timestamp of the request is random, so a reply that does not echo it
+// is rejected. Unsynchronized servers and kiss-of-death replies fail with
+// ntp_unsynchronized.
+func QueryNTP(ctx context.Context, host string, port int, timeout time.Duration) (*NTPResponse, error) {
+	req := make([]byte, ntpPacketSize)
+	req[0] = 4<<3 | 3 // version 4, client mode
+	nonce := rand.Uint64()
+	binary.BigEndian.PutUint64(req[40:], nonce)
+
+	reply, rtt, err := CheckUDP(ctx, host, port, req, timeout)
+	received := time.Now()
+	if err != nil {
+		return nil, err
+	}
+	sent := received.Add(-rtt)
+
+	if len(reply) < ntpPacketSize {
+		return nil, invalidNTPReply("reply is %d bytes", len(reply))
+	}
+	if mode := reply[0] & 0x7; mode != 4 {
+		return nil, invalidNTPReply("mode %d instead of server", mode)
+	}
+	if binary.BigEndian.Uint64(reply[24:]) != nonce {
+		return nil, invalidNTPReply("origin timestamp does not match the request")
+	}
+
+	resp := &NTPResponse{Stratum: int(reply[1])}
+	refID := reply[12:16]
+	switch {
+	case resp.Stratum == 0:
+		return nil, newProbeError(ErrorClassNTPUnsynced,
+			fmt.Errorf("kiss-of-death %q from %s", string(refID), host))
+	case reply[0]>>6 == 3 || resp.Stratum > 15:
+		return nil, newProbeError(ErrorClassNTPUnsynced,
+			fmt.Errorf("%s is not synchronized", host))
+	case resp.Stratum == 1:
+		resp.ReferenceID = string(trimNUL(refID))
+	default:
+		resp.ReferenceID = net.IP(refID).String()
+	}
+
+	serverReceive := ntpTime(binary.BigEndian.Uint64(reply[32:]))
+	serverTransmit := ntpTime(binary.BigEndian.Uint64(reply[40:]))
+	resp.Offset = (serverReceive.Sub(sent) + serverTransmit.Sub(received)) / 2
+	resp.Delay = received.Sub(sent) - serverTransmit.Sub(serverReceive)
+	if resp.Delay < 0 {
+		resp.Delay = 0
+	}
+	return resp, nil
+}
+
+func invalidNTPReply(format string, args ...interface{}) error {
+	return newProbeError(ErrorClassReplyMismatch, fmt.Errorf("invalid NTP reply: "+format, args...))
+}
+
+// ntpTime converts a 64-bit NTP timestamp. Timestamps with the high bit
+// clear are taken to be in era 1, which starts in 2036.
+func ntpTime(ts uint64) time.Time {
+	secs := ts >> 32
+	nanos := (ts & 0xffffffff) * 1e9 >> 32
+	t := ntpEpoch.Add(time.Duration(secs) * time.Second).Add(time.Duration(nanos))
+	if secs&0x80000000 == 0 {
+		t = t.Add(1 << 32 * time.Second)
+	}
+	return t
+}
+
+func trimNUL(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// targetNTPOptions applies the target's ntp overrides to opts.
+func targetNTPOptions(opts config.NTPOptions, target Target) config.NTPOptions {
+	if target.NTP.DegradedOffset > 0 {
+		opts.DegradedOffset = target.NTP.DegradedOffset
+	}
+	if target.NTP.MaxOffset > 0 {
+		opts.MaxOffset = target.NTP.MaxOffset
+	}
+	return opts
+}
+
+func validateNTPOptions(opts config.NTPOptions) error {
+	switch {
+	case opts.DegradedOffset < 0:
+		return fmt.Errorf("ntp degraded_offset must not be negative, got %s", opts.DegradedOffset)
+	case opts.MaxOffset < 0:
+		return fmt.Errorf("ntp max_offset must not be negative, got %s", opts.MaxOffset)
+	case opts.MaxOffset > 0 && opts.DegradedOffset >= opts.MaxOffset:
+		return errors.New("ntp degraded_offset must be below max_offset")
+	}
+	return nil
+}
\ No newline at end of file
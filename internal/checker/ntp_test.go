@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// newNTPServer answers SNTP requests with a clock skew ahead of the local
+// one, at the given stratum.
+func newNTPServer(t *testing.T, skew time.Duration, stratum byte) config.HostPort {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+			now := ntpTimestamp(time.Now().Add(skew))
+			reply := make([]byte, ntpPacketSize)
+			reply[0] = 4<<3 | 4
+			reply[1] = stratum
+			copy(reply[12:], "GPS")
+			if stratum == 0 {
+				copy(reply[12:], "RATE")
+			}
+			copy(reply[24:32], buf[40:48])
+			binary.BigEndian.PutUint64(reply[32:], now)
+			binary.BigEndian.PutUint64(reply[40:], now)
+			pc.WriteTo(reply, addr)
+		}
+	}()
+
+	return config.HostPort{Host: "127.0.0.1", Port: pc.LocalAddr().(*net.UDPAddr).Port}
+}
+
+// ntpTimestamp is the inverse of ntpTime.
+func ntpTimestamp(t time.Time) uint64 {
+	d := t.Sub(ntpEpoch)
+	secs := uint64(d / time.Second)
+	frac := uint64(d%time.Second) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+func TestNTPTime(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Date(2026, 10, 17, 12, 0, 0, 500000000, time.UTC),
+		time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got := ntpTime(ntpTimestamp(want)); got.Sub(want).Abs() > time.Microsecond {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestNTPProbe(t *testing.T) {
+	opts := config.NTPOptions{DegradedOffset: time.Second, MaxOffset: time.Minute}
+	probe := newNTPProbe(&config.Config{UDPTimeout: time.Second, NTP: opts})
+
+	tests := []struct {
+		name     string
+		server   config.HostPort
+		class    ErrorClass
+		degraded bool
+	}{
+		{"in sync", newNTPServer(t, 0, 1), "", false},
+		{"drifting", newNTPServer(t, -5*time.Second, 2), "", true},
+		{"skewed", newNTPServer(t, 10*time.Minute, 1), ErrorClassClockOffset, false},
+		{"kiss of death", newNTPServer(t, 0, 0), ErrorClassNTPUnsynced, false},
+		{"unsynchronized", newNTPServer(t, 0, 16), ErrorClassNTPUnsynced, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := probe.Execute(context.Background(), Target{HostPort: tt.server})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if result.Degraded != tt.degraded {
+				t.Errorf("Expected degraded %v, got %v (%v)", tt.degraded, result.Degraded, result.Details)
+			}
+			if tt.degraded && !strings.HasPrefix(result.reason, "clock offset -") {
+				t.Errorf("Expected the clock offset as degraded reason, got %q", result.reason)
+			}
+		})
+	}
+
+	t.Run("offset", func(t *testing.T) {
+		result := probe.Execute(context.Background(), Target{HostPort: newNTPServer(t, -5*time.Second, 1)})
+		offset, _ := result.Details["offset_ms"].(float64)
+		if offset > -4900 || offset < -5100 {
+			t.Errorf("Expected an offset near -5000ms, got %v", result.Details)
+		}
+		if result.Details["stratum"] != 1 || result.Details["reference_id"] != "GPS" {
+			t.Errorf("Expected stratum 1 from GPS, got %v", result.Details)
+		}
+	})
+}
+
+func TestNexaInvalidNTPOptions(t *testing.T) {
+	cfg := &config.Config{
+		NTP:       config.NTPOptions{MaxOffset: time.Minute},
+		CorpHosts: []config.HostPort{{Host: "ntp.corp.local", Probes: []string{"ntp"}, NTP: config.NTPOptions{DegradedOffset: 2 * time.Minute}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for degraded_offset above max_offset")
+	}
+}
\ No newline at end of file
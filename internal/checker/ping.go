@@ -1,27 +1,355 @@
 package checker
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"time"
 
 	"github.com/go-ping/ping"
+	"golang.org/x/net/icmp"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/internal/metrics"
 )
 
-func CheckPing(host string, timeout time.Duration, count int) bool {
-	pinger, err := ping.NewPinger(host)
+func init() {
+	RegisterProbe("ping", newPingProbe)
+}
+
+// DefaultPingCount is the number of echo requests sent when ping.count is
+// not set.
+const DefaultPingCount = 3
+
+// DefaultFallbackPort is used by the tcp fallback for targets without a
+// port.
+const DefaultFallbackPort = 443
+
+// ICMP modes. ICMPUnavailable is only reported, never configured.
+const (
+	ICMPAuto         = "auto"
+	ICMPUnprivileged = "unprivileged"
+	ICMPPrivileged   = "privileged"
+	ICMPUnavailable  = "unavailable"
+)
+
+type pingProbe struct {
+	timeout    time.Duration
+	tcpTimeout time.Duration
+	opts       config.PingOptions
+	detected   string
+
+	// acquire waits for a probe slot of the scheduler before the tcp
+	// fallback runs. Nil means no limit.
+	acquire func(ctx context.Context, name string) (func(), bool)
+}
+
+// newPingProbe detects the usable ICMP mode once, when the checker is
+// built.
+func newPingProbe(cfg *config.Config) Probe {
+	return &pingProbe{
+		timeout:    cfg.PingTimeout,
+		tcpTimeout: cfg.TCPTimeout,
+		opts:       cfg.Ping,
+		detected:   detectICMPMode(),
+	}
+}
+
+func (p *pingProbe) Name() string {
+	return "ping"
+}
+
+// Execute reports the average RTT as the probe latency and the full
+// statistics as details. Statistics are reported for failed runs too.
+// When ICMP is not permitted, the configured fallback runs instead.
+func (p *pingProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	opts := targetPingOptions(p.opts, target)
+	mode := opts.Mode
+	if mode == ICMPAuto {
+		mode = p.detected
+	}
+
+	var stats *PingStats
+	var err error
+	if mode == ICMPUnavailable {
+		stats = &PingStats{}
+		err = newProbeError(ErrorClassICMPPermission,
+			errors.New("ICMP not permitted: no ping_group_range access and no CAP_NET_RAW"))
+	} else {
+		stats, err = CheckPing(ctx, target.Host, target.timeout(p.timeout), opts.Count, opts.Interval, mode == ICMPPrivileged)
+	}
+	if ClassifyError(err) == ErrorClassICMPPermission && opts.Fallback != "" {
+		return p.runFallback(ctx, target, opts, err)
+	}
+	if err == nil && opts.MaxLoss > 0 && stats.Loss > opts.MaxLoss {
+		err = newProbeError(ErrorClassICMPLoss,
+			fmt.Errorf("%.1f%% packet loss to %s exceeds %g%%", stats.Loss, target.Host, opts.MaxLoss))
+	}
+
+	result := newProbeResult(stats.Avg, err)
+	result.Degraded = result.Success && opts.DegradedLoss > 0 && stats.Loss > opts.DegradedLoss
+	result.Details = map[string]interface{}{"icmp_mode": mode}
+	if stats.Sent > 0 {
+		for key, value := range stats.details() {
+			result.Details[key] = value
+		}
+		result.record = func(m *metrics.PrometheusMetrics, target string) {
+			m.UpdatePingStats(target, stats.Sent, stats.Received, stats.Loss, stats.rttMs())
+		}
+	}
+	if result.Degraded {
+		result.reason = fmt.Sprintf("%.1f%% packet loss", stats.Loss)
+	}
+	return result
+}
+
+// runFallback replaces an ICMP check that cannot run with a TCP connect
+// to the target's port, or fallback_port. The connect counts against the
+// tcp probe limit but ignores the target's tcp conversation settings.
+func (p *pingProbe) runFallback(ctx context.Context, target Target, opts config.PingOptions, icmpErr error) ProbeResult {
+	if target.Port <= 0 {
+		target.Port = opts.FallbackPort
+	}
+	result := p.connect(ctx, target)
+	result.Details = map[string]interface{}{
+		"icmp_mode":     ICMPUnavailable,
+		"icmp_error":    icmpErr.Error(),
+		"fallback":      opts.Fallback,
+		"fallback_port": target.Port,
+	}
+	return result
+}
+
+// connect runs the fallback TCP connect once a tcp probe slot is free.
+func (p *pingProbe) connect(ctx context.Context, target Target) ProbeResult {
+	if p.acquire != nil {
+		release, ok := p.acquire(ctx, "tcp")
+		if !ok {
+			return newProbeResult(0, ctx.Err())
+		}
+		defer release()
+	}
+	return newProbeResult(CheckTCP(ctx, target.Host, target.Port, target.timeout(p.tcpTimeout)))
+}
+
+// detectICMPMode returns the least privileged ICMP mode this process may
+// use: unprivileged datagram sockets when net.ipv4.ping_group_range covers
+// its group, raw sockets with CAP_NET_RAW, or unavailable.
+func detectICMPMode() string {
+	if conn, err := icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+		conn.Close()
+		return ICMPUnprivileged
+	}
+	if conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+		conn.Close()
+		return ICMPPrivileged
+	}
+	return ICMPUnavailable
+}
+
+// targetPingOptions applies the target's ping overrides to opts.
+func targetPingOptions(opts config.PingOptions, target Target) config.PingOptions {
+	override := target.Ping
+	if override.Count > 0 {
+		opts.Count = override.Count
+	}
+	if override.Interval > 0 {
+		opts.Interval = override.Interval
+	}
+	if override.DegradedLoss > 0 {
+		opts.DegradedLoss = override.DegradedLoss
+	}
+	if override.MaxLoss > 0 {
+		opts.MaxLoss = override.MaxLoss
+	}
+	if override.Mode != "" {
+		opts.Mode = override.Mode
+	}
+	if override.Fallback != "" {
+		opts.Fallback = override.Fallback
+	}
+	if override.FallbackPort > 0 {
+		opts.FallbackPort = override.FallbackPort
+	}
+	if opts.Count <= 0 {
+		opts.Count = DefaultPingCount
+	}
+	if opts.Mode == "" {
+		opts.Mode = ICMPAuto
+	}
+	if opts.FallbackPort <= 0 {
+		opts.FallbackPort = DefaultFallbackPort
+	}
+	return opts
+}
+
+// PingStats summarizes one ping run. Loss is a percentage and Jitter is the
+// mean difference between consecutive round-trip times.
+type PingStats struct {
+	Sent     int
+	Received int
+	Loss     float64
+	Min      time.Duration
+	Avg      time.Duration
+	Max      time.Duration
+	StdDev   time.Duration
+	Jitter   time.Duration
+}
+
+func newPingStats(s *ping.Statistics) *PingStats {
+	stats := &PingStats{
+		Sent:     s.PacketsSent,
+		Received: s.PacketsRecv,
+		Loss:     s.PacketLoss,
+		Min:      s.MinRtt,
+		Avg:      s.AvgRtt,
+		Max:      s.MaxRtt,
+		StdDev:   s.StdDevRtt,
+		Jitter:   jitter(s.Rtts),
+	}
+	if stats.Sent == 0 {
+		stats.Loss = 0
+	}
+	return stats
+}
+
+func (s *PingStats) details() map[string]interface{} {
+	return map[string]interface{}{
+		"packets_sent":        s.Sent,
+		"packets_received":    s.Received,
+		"packet_loss_percent": s.Loss,
+		"rtt_ms":              s.rttMs(),
+	}
+}
+
+// rttMs maps the RTT statistics to milliseconds.
+func (s *PingStats) rttMs() map[string]float64 {
+	return map[string]float64{
+		"min":    durationMs(s.Min),
+		"avg":    durationMs(s.Avg),
+		"max":    durationMs(s.Max),
+		"stddev": durationMs(s.StdDev),
+		"jitter": durationMs(s.Jitter),
+	}
+}
+
+// jitter returns the mean absolute difference between consecutive RTTs.
+func jitter(rtts []time.Duration) time.Duration {
+	if len(rtts) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(rtts); i++ {
+		sum += math.Abs(float64(rtts[i] - rtts[i-1]))
+	}
+	return time.Duration(sum / float64(len(rtts)-1))
+}
+
+// CheckPing sends count echo requests interval apart and returns the
+// statistics of the run, which are never nil. Each request gets timeout to
+// be answered, so the run lasts up to (count-1)*interval plus timeout.
+// Cancelling ctx stops the pinger. A zero interval uses the pinger's
+// default of one second. Privileged pings use a raw socket instead of an
+// unprivileged datagram socket.
+func CheckPing(ctx context.Context, host string, timeout time.Duration, count int, interval time.Duration, privileged bool) (*PingStats, error) {
+	ipAddr, err := resolveIPAddr(ctx, host)
 	if err != nil {
-		return false
+		return &PingStats{}, err
 	}
 
+	pinger := ping.New(host)
+	pinger.SetIPAddr(ipAddr)
+
 	pinger.Count = count
-	pinger.Timeout = timeout
-	
-	pinger.SetPrivileged(false)
+	if interval > 0 {
+		pinger.Interval = interval
+	}
+	pinger.Timeout = pingRunTimeout(timeout, count, pinger.Interval)
+
+	pinger.SetPrivileged(privileged)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pinger.Stop()
+		case <-done:
+		}
+	}()
 
 	err = pinger.Run()
+	stats := newPingStats(pinger.Statistics())
+	if ctx.Err() != nil {
+		return stats, ctx.Err()
+	}
+	if err != nil {
+		if isPermissionError(err) {
+			return stats, newProbeError(ErrorClassICMPPermission, err)
+		}
+		return stats, err
+	}
+
+	if stats.Received == 0 {
+		return stats, newProbeError(ErrorClassICMPNoReply,
+			fmt.Errorf("no echo reply from %s after %d packets", host, stats.Sent))
+	}
+	return stats, nil
+}
+
+// pingRunTimeout returns how long a run of count requests may take when
+// the last one is sent after (count-1) intervals and may take timeout to
+// be answered. The pinger stops at this deadline whatever was sent.
+func pingRunTimeout(timeout time.Duration, count int, interval time.Duration) time.Duration {
+	if count <= 1 {
+		return timeout
+	}
+	return time.Duration(count-1)*interval + timeout
+}
+
+// resolveIPAddr resolves host, preferring IPv4 like the pinger does.
+func resolveIPAddr(ctx context.Context, host string) (*net.IPAddr, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 	if err != nil {
-		return false
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
 	}
+	for i := range addrs {
+		if addrs[i].IP.To4() != nil {
+			return &addrs[i], nil
+		}
+	}
+	return &addrs[0], nil
+}
 
-	stats := pinger.Statistics()
-	return stats.PacketsRecv > 0
+func validatePingOptions(opts config.PingOptions) error {
+	switch {
+	case opts.Count < 0:
+		return fmt.Errorf("ping count must not be negative, got %d", opts.Count)
+	case opts.Interval < 0:
+		return fmt.Errorf("ping interval must not be negative, got %s", opts.Interval)
+	case opts.DegradedLoss < 0 || opts.DegradedLoss > 100:
+		return fmt.Errorf("ping degraded_loss must be between 0 and 100, got %g", opts.DegradedLoss)
+	case opts.MaxLoss < 0 || opts.MaxLoss > 100:
+		return fmt.Errorf("ping max_loss must be between 0 and 100, got %g", opts.MaxLoss)
+	case opts.MaxLoss > 0 && opts.DegradedLoss >= opts.MaxLoss:
+		return errors.New("ping degraded_loss must be below max_loss")
+	case opts.FallbackPort < 0 || opts.FallbackPort > 65535:
+		return fmt.Errorf("ping fallback_port out of range: %d", opts.FallbackPort)
+	}
+	switch opts.Mode {
+	case "", ICMPAuto, ICMPUnprivileged, ICMPPrivileged:
+	default:
+		return fmt.Errorf("invalid ping mode %q: expected auto, unprivileged or privileged", opts.Mode)
+	}
+	switch opts.Fallback {
+	case "", "tcp":
+	default:
+		return fmt.Errorf("unsupported ping fallback %q: expected tcp", opts.Fallback)
+	}
+	return nil
 }
\ No newline at end of file
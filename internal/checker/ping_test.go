@@ -0,0 +1,145 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func TestPingProbe_Loopback(t *testing.T) {
+	probe := newPingProbe(&config.Config{
+		PingTimeout: 2 * time.Second,
+		Ping:        config.PingOptions{Count: 3, Interval: 50 * time.Millisecond},
+	})
+	result := probe.Execute(context.Background(), Target{HostPort: config.HostPort{Host: "127.0.0.1"}})
+	if result.ErrorClass == ErrorClassICMPPermission {
+		t.Skip("unprivileged ICMP is not permitted on this host")
+	}
+	if !result.Success {
+		t.Fatalf("Expected loopback to answer, got %s", result.Error)
+	}
+	if mode := result.Details["icmp_mode"]; mode != ICMPUnprivileged && mode != ICMPPrivileged {
+		t.Errorf("Expected the ICMP mode that ran, got %v", mode)
+	}
+	if result.Details["packets_sent"] != 3 || result.Details["packet_loss_percent"] != 0.0 {
+		t.Errorf("Expected 3 packets without loss, got %v", result.Details)
+	}
+	if rtt, _ := result.Details["rtt_ms"].(map[string]float64); rtt["max"] < rtt["min"] {
+		t.Errorf("Expected RTT statistics, got %v", result.Details["rtt_ms"])
+	}
+}
+
+func TestPingRunTimeout(t *testing.T) {
+	// Ten packets a second apart must not be cut short by a 3s timeout.
+	if got := pingRunTimeout(3*time.Second, 10, time.Second); got != 12*time.Second {
+		t.Errorf("Expected 12s for 10 packets, got %s", got)
+	}
+	if got := pingRunTimeout(3*time.Second, 1, time.Second); got != 3*time.Second {
+		t.Errorf("Expected the reply timeout for a single packet, got %s", got)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	rtts := []time.Duration{10 * time.Millisecond, 14 * time.Millisecond, 12 * time.Millisecond}
+	if got := jitter(rtts); got != 3*time.Millisecond {
+		t.Errorf("Expected 3ms jitter, got %s", got)
+	}
+	if got := jitter(rtts[:1]); got != 0 {
+		t.Errorf("Expected no jitter for a single reply, got %s", got)
+	}
+}
+
+func TestTargetPingOptions(t *testing.T) {
+	global := config.PingOptions{Interval: time.Second, DegradedLoss: 10}
+
+	opts := targetPingOptions(global, Target{})
+	if opts.Count != DefaultPingCount || opts.DegradedLoss != 10 {
+		t.Errorf("Expected the default count and global threshold, got %+v", opts)
+	}
+
+	opts = targetPingOptions(global, Target{HostPort: config.HostPort{Ping: config.PingOptions{Count: 10, MaxLoss: 50}}})
+	if opts.Count != 10 || opts.Interval != time.Second || opts.MaxLoss != 50 {
+		t.Errorf("Expected target overrides on top of the global options, got %+v", opts)
+	}
+}
+
+func TestNexaDegraded(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{
+			{Host: "lossy.example", Probes: []string{"mock-degraded", "mock-up"}},
+			{Host: "clean.example", Probes: []string{"mock-up"}},
+		},
+		Attempts: 1,
+	}
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	if !result.InternetOK || result.Summary.Degraded != 1 {
+		t.Errorf("Expected internet up with one degraded check, got %v and %d", result.InternetOK, result.Summary.Degraded)
+	}
+	if check := result.InternetDetails["external:lossy.example:0"]; !check.Success || !check.Degraded {
+		t.Errorf("Expected lossy.example to be up but degraded, got %+v", check)
+	}
+	if reason := degradedReason(result.InternetDetails["external:lossy.example:0"]); reason != "[mock-degraded] 12.5% packet loss" {
+		t.Errorf("Expected the probe's degraded reason, got %q", reason)
+	}
+	result.PrintHuman()
+}
+
+func TestNexaInvalidPingLoss(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{{Host: "8.8.8.8", Ping: config.PingOptions{DegradedLoss: 50, MaxLoss: 20}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for degraded_loss above max_loss")
+	}
+}
+func TestPingProbe_Fallback(t *testing.T) {
+	addr, cleanup := MockTCPServer(t)
+	defer cleanup()
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	cfg := &config.Config{TCPTimeout: time.Second}
+	probe := newPingProbe(cfg).(*pingProbe)
+	probe.detected = ICMPUnavailable
+
+	target := Target{HostPort: config.HostPort{Host: host}}
+	result := probe.Execute(context.Background(), target)
+	if result.ErrorClass != ErrorClassICMPPermission || result.Details["icmp_mode"] != ICMPUnavailable {
+		t.Fatalf("Expected %s without a fallback, got %+v", ErrorClassICMPPermission, result)
+	}
+
+	// The fallback is a plain connect, whatever the tcp probe would send.
+	target.Ping = config.PingOptions{Fallback: "tcp", FallbackPort: port}
+	target.TCP = config.TCPOptions{Exchange: config.Exchange{Expect: "220"}}
+	result = probe.Execute(context.Background(), target)
+	if !result.Success || result.Details["fallback"] != "tcp" || result.Details["fallback_port"] != port {
+		t.Errorf("Expected the tcp fallback to succeed on port %d, got %+v", port, result)
+	}
+
+	s := newScheduler(&config.Config{ProbeLimits: map[string]int{"tcp": 1}}, nil)
+	probe.acquire = s.acquireProbe
+	release, _ := s.acquireProbe(context.Background(), "tcp")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result = probe.Execute(ctx, target)
+	release()
+	if result.Success {
+		t.Errorf("Expected the tcp fallback to wait for a tcp slot, got %+v", result)
+	}
+}
+
+func TestNexaInvalidPingMode(t *testing.T) {
+	cfg := &config.Config{Ping: config.PingOptions{Mode: "raw"}}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an unknown ping mode")
+	}
+}
\ No newline at end of file
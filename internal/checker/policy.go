@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Policy decides whether a group is up from the results of its targets.
+// Each target counts with its weight (1 unless configured).
+//
+//	any          at least one target succeeded (default)
+//	all          every target succeeded
+//	at_least:N   the successful weight is at least N
+//	percent:P    the successful weight is at least P% of the total weight
+type Policy struct {
+	Mode  string
+	Value float64
+}
+
+const (
+	PolicyAny     = "any"
+	PolicyAll     = "all"
+	PolicyAtLeast = "at_least"
+	PolicyPercent = "percent"
+)
+
+// ParsePolicy parses a policy expression. An empty string yields "any".
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Policy{Mode: PolicyAny}, nil
+	}
+
+	mode, arg, hasArg := strings.Cut(s, ":")
+	mode = strings.TrimSpace(mode)
+	switch mode {
+	case PolicyAny, PolicyAll:
+		if hasArg {
+			return Policy{}, fmt.Errorf("policy %q takes no argument", mode)
+		}
+		return Policy{Mode: mode}, nil
+	case PolicyAtLeast, PolicyPercent:
+		value, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if !hasArg || err != nil || value <= 0 {
+			return Policy{}, fmt.Errorf("policy %q needs a positive number, e.g. %s:2", s, mode)
+		}
+		if mode == PolicyPercent && value > 100 {
+			return Policy{}, fmt.Errorf("policy %q exceeds 100 percent", s)
+		}
+		return Policy{Mode: mode, Value: value}, nil
+	default:
+		return Policy{}, fmt.Errorf("unknown policy %q", s)
+	}
+}
+
+func (p Policy) String() string {
+	switch p.Mode {
+	case PolicyAtLeast, PolicyPercent:
+		return fmt.Sprintf("%s:%s", p.Mode, strconv.FormatFloat(p.Value, 'f', -1, 64))
+	default:
+		return p.Mode
+	}
+}
+
+// Evaluate applies the policy to a set of check results and returns the
+// outcome together with the successful and total weight.
+func (p Policy) Evaluate(checks map[string]CheckResult) (ok bool, passed, total int) {
+	for _, check := range checks {
+		weight := check.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		total += weight
+		if check.Success {
+			passed += weight
+		}
+	}
+
+	switch p.Mode {
+	case PolicyAll:
+		ok = total > 0 && passed == total
+	case PolicyAtLeast:
+		ok = float64(passed) >= p.Value
+	case PolicyPercent:
+		ok = total > 0 && float64(passed)*100 >= p.Value*float64(total)
+	default:
+		ok = passed > 0
+	}
+	return ok, passed, total
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package checker
+
+import "testing"
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "any", false},
+		{"any", "any", false},
+		{"all", "all", false},
+		{"at_least:2", "at_least:2", false},
+		{"percent: 60", "percent:60", false},
+		{"percent:150", "", true},
+		{"at_least", "", true},
+		{"at_least:-1", "", true},
+		{"all:3", "", true},
+		{"majority", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			policy, err := ParsePolicy(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := policy.String(); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	checks := map[string]CheckResult{
+		"a": {Success: true, Weight: 3},
+		"b": {Success: false},
+		"c": {Success: true},
+		"d": {Success: false},
+	}
+
+	testCases := []struct {
+		policy string
+		want   bool
+	}{
+		{"any", true},
+		{"all", false},
+		{"at_least:4", true},
+		{"at_least:5", false},
+		{"percent:60", true},
+		{"percent:70", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			policy, err := ParsePolicy(tc.policy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			ok, passed, total := policy.Evaluate(checks)
+			if ok != tc.want {
+				t.Errorf("Expected %v, got %v", tc.want, ok)
+			}
+			if passed != 4 || total != 6 {
+				t.Errorf("Expected weight 4/6, got %d/%d", passed, total)
+			}
+		})
+	}
+
+	if ok, _, _ := (Policy{Mode: PolicyAll}).Evaluate(nil); ok {
+		t.Error("Expected empty group to fail the all policy")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,129 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/internal/metrics"
+)
+
+// Probe is a single protocol check executed against a target.
+type Probe interface {
+	Name() string
+	Execute(ctx context.Context, target Target) ProbeResult
+}
+
+// ProbeFactory builds a probe from the global configuration.
+type ProbeFactory func(cfg *config.Config) Probe
+
+// Target is a host as seen by a probe, together with the check type it
+// belongs to.
+type Target struct {
+	config.HostPort
+	Type CheckType
+}
+
+func (t Target) String() string {
+	if t.Port > 0 {
+		return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+	}
+	return t.Host
+}
+
+// timeout returns the per-target timeout, or def when none is set.
+func (t Target) timeout(def time.Duration) time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	return def
+}
+
+// ProbeResult is the outcome of a single probe against a single target.
+// LatencyMs is the protocol-level timing of the last attempt (connect time,
+// RTT, resolution time or time to first byte) and Attempts is the number of
+// attempts that were needed. Degraded marks a success that crossed a
+// warning threshold, such as packet loss.
+type ProbeResult struct {
+	Probe      string                 `json:"probe"`
+	Success    bool                   `json:"success"`
+	Degraded   bool                   `json:"degraded,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	ErrorClass ErrorClass             `json:"error_class,omitempty"`
+	LatencyMs  float64                `json:"latency_ms"`
+	Attempts   int                    `json:"attempts"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+
+	// record updates the probe's own metrics for target, if it has any.
+	record func(m *metrics.PrometheusMetrics, target string)
+	// reason explains a degraded result in the human output.
+	reason string
+	// checkDetails are merged into the details of the enclosing CheckResult.
+	checkDetails map[string]interface{}
+}
+
+// newProbeResult builds the result of a single probe attempt. The exported
+// Check and Query helpers take the context first and the timeout last and
+// return the protocol latency, or a value carrying it, together with an
+// error. Probes pass both here; errors ClassifyError cannot recognise are
+// wrapped with newProbeError by the helper.
+func newProbeResult(latency time.Duration, err error) ProbeResult {
+	result := ProbeResult{LatencyMs: durationMs(latency)}
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorClass = ClassifyError(err)
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ProbeFactory)
+)
+
+// RegisterProbe makes a probe available under name. It panics if the name
+// is already taken, so it is meant to be called from init functions.
+func RegisterProbe(name string, factory ProbeFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("checker: probe %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// ProbeNames returns the names of all registered probes in sorted order.
+func ProbeNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newProbes(cfg *config.Config) map[string]Probe {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	probes := make(map[string]Probe, len(registry))
+	for name, factory := range registry {
+		probes[name] = factory(cfg)
+	}
+	return probes
+}
\ No newline at end of file
@@ -0,0 +1,252 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("scenario", newScenarioProbe)
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+type scenarioProbe struct {
+	timeout time.Duration
+}
+
+func newScenarioProbe(cfg *config.Config) Probe {
+	return &scenarioProbe{timeout: cfg.HTTPTimeout}
+}
+
+func (p *scenarioProbe) Name() string {
+	return "scenario"
+}
+
+// StepResult is the outcome of one scenario step.
+type StepResult struct {
+	Name      string  `json:"name"`
+	URL       string  `json:"url"`
+	Success   bool    `json:"success"`
+	Status    int     `json:"status,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Execute runs the target's steps in order with a shared cookie jar and
+// stops at the first failing step. The probe latency is the time taken by
+// all steps together.
+func (p *scenarioProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if len(target.Scenario) == 0 {
+		return newProbeResult(0, newProbeError(ErrorClassConfig,
+			fmt.Errorf("no scenario configured for %s", target.Host)))
+	}
+
+	steps, elapsed, err := RunScenario(ctx, target.Scenario, target.timeout(p.timeout))
+	result := newProbeResult(elapsed, err)
+	result.Details = map[string]interface{}{"steps": steps}
+	if err != nil {
+		result.Details["failed_step"] = steps[len(steps)-1].Name
+	}
+	return result
+}
+
+// RunScenario executes steps in order, passing cookies and extracted
+// variables from one step to the next. Each step gets its own timeout.
+func RunScenario(ctx context.Context, steps []config.ScenarioStep, timeout time.Duration) ([]StepResult, time.Duration, error) {
+	jar, _ := cookiejar.New(nil)
+	vars := make(map[string]string)
+	results := make([]StepResult, 0, len(steps))
+
+	start := time.Now()
+	for i, step := range steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step%d", i+1)
+		}
+		url := expandURL(step.URL, vars)
+		opts := expandOptions(step.HTTPOptions, vars)
+
+		client := newHTTPClient(timeout, opts.MaxRedirects)
+		client.Jar = jar
+
+		stepStart := time.Now()
+		exchange, err := doHTTP(ctx, client, url, opts)
+		if err == nil {
+			err = exchange.verify(opts)
+		}
+		if err == nil {
+			err = extractVars(exchange, step.Extract, vars)
+		}
+
+		result := StepResult{
+			Name:      name,
+			URL:       url,
+			Success:   err == nil,
+			Status:    exchange.Status,
+			LatencyMs: durationMs(time.Since(stepStart)),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			return results, time.Since(start), newProbeError(ClassifyError(err), fmt.Errorf("step %s: %w", name, err))
+		}
+		results = append(results, result)
+	}
+	return results, time.Since(start), nil
+}
+
+// extractVars stores the values selected by extracts in vars.
+func extractVars(exchange *httpExchange, extracts []config.Extract, vars map[string]string) error {
+	for _, extract := range extracts {
+		var value string
+		switch {
+		case extract.JSON != "":
+			var doc interface{}
+			if err := json.Unmarshal(exchange.Body, &doc); err != nil {
+				return assertionError("extract %s: body is not JSON: %v", extract.Var, err)
+			}
+			v, ok := jsonPath(doc, extract.JSON)
+			if !ok {
+				return assertionError("extract %s: json %s not found", extract.Var, extract.JSON)
+			}
+			value = jsonString(v)
+		case extract.Regex != "":
+			re, err := regexp.Compile(extract.Regex)
+			if err != nil {
+				return newProbeError(ErrorClassConfig, err)
+			}
+			m := re.FindSubmatch(exchange.Body)
+			if m == nil {
+				return assertionError("extract %s: body does not match %q", extract.Var, extract.Regex)
+			}
+			value = string(m[len(m)-1])
+		case extract.Header != "":
+			value = exchange.Header.Get(extract.Header)
+			if value == "" {
+				return assertionError("extract %s: header %s not set", extract.Var, extract.Header)
+			}
+		}
+		vars[extract.Var] = value
+	}
+	return nil
+}
+
+// expandVars replaces "{{name}}" placeholders with extracted variables;
+// unknown names are left as is.
+func expandVars(s string, vars map[string]string) string {
+	return expandEscaped(s, vars, func(value string) string { return value })
+}
+
+// expandEscaped is expandVars with every substituted value passed through
+// escape.
+func expandEscaped(s string, vars map[string]string, escape func(string) string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		if value, ok := vars[placeholder.FindStringSubmatch(m)[1]]; ok {
+			return escape(value)
+		}
+		return m
+	})
+}
+
+// expandURL expands placeholders in a step URL, path-escaping values before
+// the query and query-escaping values after it.
+func expandURL(raw string, vars map[string]string) string {
+	path, query, hasQuery := strings.Cut(raw, "?")
+	expanded := expandEscaped(path, vars, neturl.PathEscape)
+	if hasQuery {
+		expanded += "?" + expandEscaped(query, vars, neturl.QueryEscape)
+	}
+	return expanded
+}
+
+func expandOptions(opts config.HTTPOptions, vars map[string]string) config.HTTPOptions {
+	opts.Body = expandVars(opts.Body, vars)
+	opts.Username = expandVars(opts.Username, vars)
+	opts.Password = expandVars(opts.Password, vars)
+	opts.BearerToken = expandVars(opts.BearerToken, vars)
+
+	headers := make(map[string]string, len(opts.Headers))
+	for name, value := range opts.Headers {
+		headers[name] = expandVars(value, vars)
+	}
+	opts.Headers = headers
+
+	contains := make([]string, len(opts.BodyContains))
+	for i, want := range opts.BodyContains {
+		contains[i] = expandVars(want, vars)
+	}
+	opts.BodyContains = contains
+
+	// Values in regular expressions match literally.
+	matches := make([]string, len(opts.BodyMatches))
+	for i, pattern := range opts.BodyMatches {
+		matches[i] = expandEscaped(pattern, vars, regexp.QuoteMeta)
+	}
+	opts.BodyMatches = matches
+
+	headerMatches := make(map[string]string, len(opts.HeaderMatches))
+	for name, pattern := range opts.HeaderMatches {
+		headerMatches[name] = expandEscaped(pattern, vars, regexp.QuoteMeta)
+	}
+	opts.HeaderMatches = headerMatches
+
+	assertions := make([]config.JSONAssertion, len(opts.JSON))
+	for i, assertion := range opts.JSON {
+		assertion.Equals = expandVars(assertion.Equals, vars)
+		assertions[i] = assertion
+	}
+	opts.JSON = assertions
+	return opts
+}
+
+func validateScenario(target Target) error {
+	for i, step := range target.Scenario {
+		if step.URL == "" {
+			return fmt.Errorf("host %s: scenario step %d has no url", target.Host, i+1)
+		}
+		if err := validateHTTPOptions(target.Host, step.HTTPOptions); err != nil {
+			return err
+		}
+		for _, extract := range step.Extract {
+			if err := validateExtract(extract); err != nil {
+				return fmt.Errorf("host %s: scenario step %d: %v", target.Host, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateExtract(extract config.Extract) error {
+	if extract.Var == "" {
+		return errors.New("extract needs a var")
+	}
+
+	sources := 0
+	for _, source := range []string{extract.JSON, extract.Regex, extract.Header} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("extract %s needs exactly one of json, regex or header", extract.Var)
+	}
+	if extract.Regex != "" {
+		if _, err := regexp.Compile(extract.Regex); err != nil {
+			return fmt.Errorf("extract %s: invalid regex: %v", extract.Var, err)
+		}
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func newPortalAppServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"csrf":"token-123"}`)
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-CSRF") != "token-123" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		http.Redirect(w, r, "/home", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<h1>Welcome</h1>`)
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"user":"nexa"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScenarioProbe(t *testing.T) {
+	server := newPortalAppServer(t)
+	probe := newScenarioProbe(&config.Config{HTTPTimeout: 2 * time.Second})
+
+	steps := []config.ScenarioStep{
+		{Name: "login", URL: server.URL + "/login", Extract: []config.Extract{{Var: "csrf", JSON: "csrf"}}},
+		{Name: "session", URL: server.URL + "/session", HTTPOptions: config.HTTPOptions{
+			Method:       "POST",
+			Headers:      map[string]string{"X-CSRF": "{{csrf}}"},
+			MaxRedirects: 1,
+			BodyContains: []string{"Welcome"},
+		}},
+		{Name: "me", URL: server.URL + "/me", HTTPOptions: config.HTTPOptions{
+			JSON: []config.JSONAssertion{{Path: "user", Equals: "nexa"}},
+		}},
+	}
+
+	result := probe.Execute(context.Background(), Target{HostPort: config.HostPort{Scenario: steps}})
+	if !result.Success {
+		t.Fatalf("Expected the scenario to succeed, got %s", result.Error)
+	}
+	stepResults := result.Details["steps"].([]StepResult)
+	if len(stepResults) != 3 || stepResults[1].Status != http.StatusOK {
+		t.Errorf("Expected three steps with the redirect followed, got %+v", stepResults)
+	}
+
+	// Without the extracted token the session is refused and the cookie
+	// protected step is never reached.
+	steps[0].Extract = nil
+	result = probe.Execute(context.Background(), Target{HostPort: config.HostPort{Scenario: steps}})
+	if result.Success || result.Details["failed_step"] != "session" || result.ErrorClass != ErrorClassHTTPStatus {
+		t.Errorf("Expected the session step to fail with %s, got %+v", ErrorClassHTTPStatus, result)
+	}
+	if got := len(result.Details["steps"].([]StepResult)); got != 2 {
+		t.Errorf("Expected the scenario to stop after 2 steps, got %d", got)
+	}
+}
+
+func TestExpandURL(t *testing.T) {
+	vars := map[string]string{"id": "a b/c", "q": "x&y=z"}
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com/items/{{id}}", "https://example.com/items/a%20b%2Fc"},
+		{"https://example.com/search?q={{q}}&id={{id}}", "https://example.com/search?q=x%26y%3Dz&id=a+b%2Fc"},
+		{"https://example.com/{{unknown}}", "https://example.com/{{unknown}}"},
+	}
+
+	for _, tt := range tests {
+		if got := expandURL(tt.raw, vars); got != tt.want {
+			t.Errorf("expandURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestExpandOptions_Matches(t *testing.T) {
+	opts := expandOptions(config.HTTPOptions{
+		BodyMatches:   []string{`user=\w+ id={{id}}`},
+		HeaderMatches: map[string]string{"X-Request": "^{{id}}$"},
+	}, map[string]string{"id": "1.2+3"})
+
+	if want := `user=\w+ id=1\.2\+3`; opts.BodyMatches[0] != want {
+		t.Errorf("Expected body pattern %q, got %q", want, opts.BodyMatches[0])
+	}
+	if want := `^1\.2\+3$`; opts.HeaderMatches["X-Request"] != want {
+		t.Errorf("Expected header pattern %q, got %q", want, opts.HeaderMatches["X-Request"])
+	}
+}
+
+func TestNexaInvalidScenario(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "portal", Scenario: []config.ScenarioStep{
+			{URL: "http://portal/", Extract: []config.Extract{{Var: "token", JSON: "token", Header: "X-Token"}}},
+		}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an extract with two sources")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/internal/metrics"
+)
+
+// scheduler bounds how many checks run at once: globally through the
+// number of workers, per destination host, and per probe type.
+type scheduler struct {
+	workers     int
+	perHost     int
+	probeLimits map[string]int
+	metrics     *metrics.PrometheusMetrics
+
+	mu         sync.Mutex
+	hostSlots  map[string]chan struct{}
+	probeSlots map[string]chan struct{}
+}
+
+func newScheduler(cfg *config.Config, m *metrics.PrometheusMetrics) *scheduler {
+	return &scheduler{
+		workers:     cfg.Workers,
+		perHost:     cfg.MaxPerHost,
+		probeLimits: cfg.ProbeLimits,
+		metrics:     m,
+		hostSlots:   make(map[string]chan struct{}),
+		probeSlots:  make(map[string]chan struct{}),
+	}
+}
+
+// run calls fn for every target with at most s.workers targets running at
+// once; a worker count of zero does not limit them. A target takes its host
+// slot before a worker, so targets waiting for a busy host do not hold
+// workers from other hosts. Targets still queued when ctx is cancelled are
+// skipped.
+func (s *scheduler) run(ctx context.Context, targets []Target, fn func(Target), skipped func(Target)) {
+	var workerSlots chan struct{}
+	if s.workers > 0 && s.workers < len(targets) {
+		workerSlots = make(chan struct{}, s.workers)
+	}
+
+	queued := time.Now()
+	pending := int64(len(targets))
+	s.updateQueue(pending, 0, false)
+
+	dequeue := func(started bool) {
+		depth := atomic.AddInt64(&pending, -1)
+		s.updateQueue(depth, time.Since(queued), started)
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+			releaseHost, ok := s.acquire(ctx, s.hostSlot(target.Host))
+			if !ok {
+				dequeue(false)
+				skipped(target)
+				return
+			}
+			defer releaseHost()
+
+			releaseWorker, ok := s.acquire(ctx, workerSlots)
+			if !ok {
+				dequeue(false)
+				skipped(target)
+				return
+			}
+			defer releaseWorker()
+
+			dequeue(true)
+			fn(target)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// acquireProbe waits for a free slot for the named probe type. The returned
+// release function must be called once the probe is done.
+func (s *scheduler) acquireProbe(ctx context.Context, name string) (func(), bool) {
+	return s.acquire(ctx, s.probeSlot(name))
+}
+
+func (s *scheduler) acquire(ctx context.Context, slots chan struct{}) (func(), bool) {
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	if slots == nil {
+		return func() {}, true
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+func (s *scheduler) hostSlot(host string) chan struct{} {
+	if host == "" {
+		return nil
+	}
+	return s.slot(s.hostSlots, host, s.perHost)
+}
+
+func (s *scheduler) probeSlot(name string) chan struct{} {
+	return s.slot(s.probeSlots, name, s.probeLimits[name])
+}
+
+func (s *scheduler) slot(slots map[string]chan struct{}, key string, limit int) chan struct{} {
+	if limit <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := slots[key]
+	if !ok {
+		ch = make(chan struct{}, limit)
+		slots[key] = ch
+	}
+	return ch
+}
+
+func (s *scheduler) updateQueue(depth int64, wait time.Duration, dequeued bool) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.UpdateQueueDepth(int(depth))
+	if dequeued {
+		s.metrics.ObserveQueueWait(wait.Seconds())
+	}
+}
\ No newline at end of file
@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func slowHosts(n int, host func(i int) string) []config.HostPort {
+	hosts := make([]config.HostPort, n)
+	for i := range hosts {
+		hosts[i] = config.HostPort{Host: host(i), Port: i + 1, Probes: []string{"mock-slow"}}
+	}
+	return hosts
+}
+
+func TestSchedulerLimits(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     config.Config
+		wantMax int32
+	}{
+		{
+			name: "workers",
+			cfg: config.Config{
+				CorpHosts: slowHosts(6, func(i int) string { return fmt.Sprintf("host%d", i) }),
+				Workers:   2,
+			},
+			wantMax: 2,
+		},
+		{
+			name: "max per host",
+			cfg: config.Config{
+				CorpHosts:  slowHosts(4, func(i int) string { return "fileserver.corp.local" }),
+				Workers:    4,
+				MaxPerHost: 1,
+			},
+			wantMax: 1,
+		},
+		{
+			name: "probe limit",
+			cfg: config.Config{
+				CorpHosts:   slowHosts(4, func(i int) string { return fmt.Sprintf("host%d", i) }),
+				Workers:     4,
+				ProbeLimits: map[string]int{"mock-slow": 1},
+			},
+			wantMax: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Attempts = 1
+			checker, err := NewNexa(&tc.cfg)
+			if err != nil {
+				t.Fatalf("Failed to create Nexa: %v", err)
+			}
+
+			mockSlow.reset()
+			result := checker.Run()
+			if got := atomic.LoadInt32(&mockSlow.max); got != tc.wantMax {
+				t.Errorf("Expected at most %d concurrent probes, got %d", tc.wantMax, got)
+			}
+			if len(result.CorporateDetails) != len(tc.cfg.CorpHosts) {
+				t.Errorf("Expected %d results, got %d", len(tc.cfg.CorpHosts), len(result.CorporateDetails))
+			}
+		})
+	}
+}
+
+// TestSchedulerBusyHost checks that targets waiting for a busy host do not
+// keep workers from targets on other hosts.
+func TestSchedulerBusyHost(t *testing.T) {
+	s := newScheduler(&config.Config{Workers: 2, MaxPerHost: 1}, nil)
+	targets := []Target{
+		{HostPort: config.HostPort{Host: "fileserver.corp.local", Port: 445}},
+		{HostPort: config.HostPort{Host: "fileserver.corp.local", Port: 139}},
+		{HostPort: config.HostPort{Host: "printer.corp.local", Port: 631}},
+	}
+
+	printed := make(chan struct{})
+	var blocked int32
+	s.run(context.Background(), targets, func(target Target) {
+		if target.Host == "printer.corp.local" {
+			close(printed)
+			return
+		}
+		select {
+		case <-printed:
+		case <-time.After(time.Second):
+			atomic.AddInt32(&blocked, 1)
+		}
+	}, func(Target) {})
+
+	if blocked > 0 {
+		t.Error("Expected the printer to run while the file server was busy")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,249 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("split_horizon", newSplitHorizonProbe)
+}
+
+// SystemResolver names the operating system resolver in resolver lists.
+const SystemResolver = "system"
+
+// DNS views reported by the split_horizon probe.
+const (
+	ViewInternal = "internal"
+	ViewExternal = "external"
+	ViewMismatch = "mismatch"
+	ViewError    = "error"
+)
+
+type splitHorizonProbe struct {
+	cfg     config.SplitHorizon
+	timeout time.Duration
+}
+
+func newSplitHorizonProbe(cfg *config.Config) Probe {
+	return &splitHorizonProbe{cfg: cfg.SplitHorizon, timeout: cfg.DNSTimeout}
+}
+
+func (p *splitHorizonProbe) Name() string {
+	return "split_horizon"
+}
+
+// Execute resolves the name through every local and external resolver and
+// succeeds when all local resolvers see the internal view.
+func (p *splitHorizonProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if p.cfg.Name == "" {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, errors.New("no split_horizon name configured")))
+	}
+
+	local := p.cfg.Resolvers
+	if len(local) == 0 {
+		local = []string{SystemResolver}
+	}
+
+	start := time.Now()
+	answers, errs := p.resolveAll(ctx, append(append([]string(nil), local...), p.cfg.External...), target.timeout(p.timeout))
+	latency := time.Since(start)
+
+	var external []string
+	for _, resolver := range p.cfg.External {
+		external = append(external, answers[resolver]...)
+	}
+
+	views := make(map[string]string, len(local))
+	for _, resolver := range local {
+		views[resolver] = classifyView(answers[resolver], errs[resolver], external, p.cfg.Internal)
+	}
+	view := combineViews(views)
+
+	// Without a public answer every local answer would look internal.
+	failedExternal := failedResolvers(p.cfg.External, errs)
+	if len(failedExternal) == len(p.cfg.External) {
+		view = ViewError
+	}
+
+	var err error
+	switch {
+	case ctx.Err() != nil:
+		err = ctx.Err()
+	case view == ViewError:
+		failed := failedResolvers(local, errs)
+		if len(failed) == 0 {
+			failed = failedExternal
+		}
+		err = resolverError(failed[0], errs[failed[0]])
+	case view == ViewExternal:
+		err = newProbeError(ErrorClassDNSExternal, fmt.Errorf("%s resolves to its external view", p.cfg.Name))
+	case view == ViewMismatch:
+		err = newProbeError(ErrorClassDNSMismatch, fmt.Errorf("%s: resolvers disagree: %v", p.cfg.Name, views))
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = map[string]interface{}{
+		"name":    p.cfg.Name,
+		"view":    view,
+		"views":   views,
+		"answers": answers,
+	}
+	if failed := append(failedResolvers(local, errs), failedExternal...); len(failed) > 0 {
+		messages := make(map[string]string, len(failed))
+		for _, resolver := range failed {
+			messages[resolver] = errs[resolver].Error()
+		}
+		result.Details["errors"] = messages
+	}
+	return result
+}
+
+// resolveAll queries every resolver concurrently. Failed queries, including
+// NXDOMAIN, yield no answers and an error.
+func (p *splitHorizonProbe) resolveAll(ctx context.Context, resolvers []string, timeout time.Duration) (map[string][]string, map[string]error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	answers := make(map[string][]string, len(resolvers))
+	errs := make(map[string]error)
+	for _, resolver := range resolvers {
+		query := config.DNSProbe{Name: p.cfg.Name, Type: p.cfg.Type, Protocol: p.cfg.Protocol}
+		if resolver != SystemResolver {
+			query.Server = resolver
+		}
+
+		wg.Add(1)
+		go func(resolver string) {
+			defer wg.Done()
+			answer, _, err := QueryDNS(ctx, query, timeout)
+			mu.Lock()
+			answers[resolver] = normalizeAnswers(answer.Answers)
+			if err != nil {
+				errs[resolver] = err
+			}
+			mu.Unlock()
+		}(resolver)
+	}
+	wg.Wait()
+	return answers, errs
+}
+
+// classifyView compares the answers of a local resolver with the public
+// answers and the known internal answers. Without internal answers, any
+// answer that differs from the public one counts as the internal view. A
+// resolver that fails for any reason but NXDOMAIN sees no view at all.
+func classifyView(local []string, err error, external, internal []string) string {
+	switch {
+	case err != nil && ClassifyError(err) != ErrorClassDNSNXDomain:
+		return ViewError
+	case len(local) == 0, sameAnswers(local, external):
+		return ViewExternal
+	case len(internal) == 0:
+		return ViewInternal
+	case overlaps(local, normalizeAnswers(internal)):
+		return ViewInternal
+	}
+	return ViewMismatch
+}
+
+// combineViews returns the common view of all local resolvers, or mismatch
+// if they disagree. A failed local resolver makes the whole view an error.
+func combineViews(views map[string]string) string {
+	view := ""
+	for _, v := range views {
+		if v == ViewError {
+			return ViewError
+		}
+		switch {
+		case view == "":
+			view = v
+		case v != view:
+			view = ViewMismatch
+		}
+	}
+	return view
+}
+
+// failedResolvers returns the resolvers whose query failed for any reason
+// but NXDOMAIN, in order.
+func failedResolvers(resolvers []string, errs map[string]error) []string {
+	var failed []string
+	for _, resolver := range resolvers {
+		if err := errs[resolver]; err != nil && ClassifyError(err) != ErrorClassDNSNXDomain {
+			failed = append(failed, resolver)
+		}
+	}
+	return failed
+}
+
+// resolverError names the resolver in its query error, keeping its class.
+func resolverError(resolver string, err error) error {
+	return newProbeError(ClassifyError(err), fmt.Errorf("resolver %s: %v", resolver, err))
+}
+
+func normalizeAnswers(answers []string) []string {
+	normalized := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		normalized = append(normalized, normalizeAnswer(answer))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// sameAnswers reports whether two non-empty answer lists hold the same set
+// of values.
+func sameAnswers(a, b []string) bool {
+	setA, setB := answerSet(a), answerSet(b)
+	if len(setA) == 0 || len(setA) != len(setB) {
+		return false
+	}
+	for v := range setA {
+		if !setB[v] {
+			return false
+		}
+	}
+	return true
+}
+
+func answerSet(answers []string) map[string]bool {
+	set := make(map[string]bool, len(answers))
+	for _, v := range answers {
+		set[v] = true
+	}
+	return set
+}
+
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitHorizonView returns the view seen by the run-level split_horizon
+// check, if it ran.
+func splitHorizonView(global map[string]CheckResult) string {
+	view, _ := global["split_horizon"].Probes["split_horizon"].Details["view"].(string)
+	return view
+}
+
+func validateSplitHorizon(cfg *config.Config) error {
+	sh := cfg.SplitHorizon
+	if sh.Name == "" {
+		return nil
+	}
+	if len(sh.External) == 0 {
+		return errors.New("split_horizon needs at least one external resolver")
+	}
+	return validateDNSQuery(config.DNSProbe{Type: sh.Type, Protocol: sh.Protocol})
+}
\ No newline at end of file
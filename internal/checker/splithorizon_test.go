@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func viewZone(ip [4]byte) zone {
+	return zone{"intranet.corp.example. A": {{
+		Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA, TTL: 60},
+		Body:   &dnsmessage.AResource{A: ip},
+	}}}
+}
+
+func TestSplitHorizonProbe(t *testing.T) {
+	internal, _ := newDNSServer(t, viewZone([4]byte{10, 1, 1, 1}))
+	external, _ := newDNSServer(t, viewZone([4]byte{203, 0, 113, 10}))
+	nxdomain, _ := newDNSServer(t, zone{})
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	down := silent.LocalAddr().String()
+
+	tests := []struct {
+		name      string
+		resolvers []string
+		public    []string
+		internal  []string
+		view      string
+		class     ErrorClass
+	}{
+		{"internal view", []string{internal}, nil, nil, ViewInternal, ""},
+		{"known internal answer", []string{internal}, nil, []string{"10.1.1.1"}, ViewInternal, ""},
+		{"external view", []string{external}, nil, nil, ViewExternal, ErrorClassDNSExternal},
+		{"not resolvable", []string{nxdomain}, nil, nil, ViewExternal, ErrorClassDNSExternal},
+		{"unknown internal answer", []string{internal}, nil, []string{"10.9.9.9"}, ViewMismatch, ErrorClassDNSMismatch},
+		{"resolvers disagree", []string{internal, external}, nil, nil, ViewMismatch, ErrorClassDNSMismatch},
+		{"resolver timeout", []string{down}, nil, nil, ViewError, ErrorClassDNSTimeout},
+		{"one resolver down", []string{internal, down}, nil, nil, ViewError, ErrorClassDNSTimeout},
+		{"public resolvers down", []string{external}, []string{down}, nil, ViewError, ErrorClassDNSTimeout},
+		{"one public resolver down", []string{internal}, []string{down, external}, nil, ViewInternal, ""},
+		{"public name missing", []string{internal}, []string{nxdomain}, nil, ViewInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public := tt.public
+			if public == nil {
+				public = []string{external}
+			}
+			probe := newSplitHorizonProbe(&config.Config{
+				DNSTimeout: 500 * time.Millisecond,
+				SplitHorizon: config.SplitHorizon{
+					Name:      "intranet.corp.example",
+					Resolvers: tt.resolvers,
+					External:  public,
+					Internal:  tt.internal,
+				},
+			})
+			result := probe.Execute(context.Background(), Target{})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if view := result.Details["view"]; view != tt.view {
+				t.Errorf("Expected view %s, got %v", tt.view, view)
+			}
+			failed, _ := result.Details["errors"].(map[string]string)
+			for _, resolver := range append(tt.resolvers, public...) {
+				if _, ok := failed[resolver]; ok != (resolver == down) {
+					t.Errorf("Expected errors to list only %s, got %v", down, failed)
+				}
+			}
+		})
+	}
+}
+
+func TestNexaInvalidSplitHorizon(t *testing.T) {
+	cfg := &config.Config{SplitHorizon: config.SplitHorizon{Name: "intranet.corp.example"}}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for split_horizon without external resolvers")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,229 @@
+package checker
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("ssh", newSSHProbe)
+}
+
+// DefaultSSHPort is used by the ssh probe for targets without a port.
+const DefaultSSHPort = 22
+
+// maxSSHPreamble bounds what is kept of the server's first bytes, which
+// hold its identification line.
+const maxSSHPreamble = 8192
+
+// sshHostKeyAlgorithms follows the OpenSSH client order so that the host
+// key compared is the one ssh-keyscan and known_hosts usually show.
+var sshHostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA,
+}
+
+// errHostKeyReceived stops the handshake once the host key is known, so
+// the probe never authenticates.
+var errHostKeyReceived = errors.New("host key received")
+
+type sshProbe struct {
+	timeout time.Duration
+}
+
+func newSSHProbe(cfg *config.Config) Probe {
+	return &sshProbe{timeout: cfg.SSHTimeout}
+}
+
+func (p *sshProbe) Name() string {
+	return "ssh"
+}
+
+func (p *sshProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	port := target.Port
+	if port <= 0 {
+		port = DefaultSSHPort
+	}
+
+	info, latency, err := CheckSSH(ctx, target.Host, port, target.timeout(p.timeout))
+	if err == nil && len(target.HostKeyFingerprints) > 0 && !matchHostKey(info.Fingerprint, target.HostKeyFingerprints) {
+		err = newProbeError(ErrorClassSSHHostKey,
+			fmt.Errorf("%s host key %s of %s does not match host_key_fingerprints", info.HostKeyType, info.Fingerprint, target.Host))
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = info.details()
+	if info.Software != "" {
+		result.checkDetails = map[string]interface{}{"ssh_version": info.Software}
+	}
+	return result
+}
+
+// SSHInfo describes an SSH server as far as the key exchange: its
+// identification banner and host key.
+type SSHInfo struct {
+	Banner      string
+	Protocol    string
+	Software    string
+	Comments    string
+	HostKeyType string
+	Fingerprint string
+}
+
+func (i *SSHInfo) details() map[string]interface{} {
+	details := make(map[string]interface{})
+	if i.Banner != "" {
+		details["banner"] = i.Banner
+		details["protocol_version"] = i.Protocol
+		details["software_version"] = i.Software
+		if i.Comments != "" {
+			details["comments"] = i.Comments
+		}
+	}
+	if i.Fingerprint != "" {
+		details["host_key_type"] = i.HostKeyType
+		details["host_key_fingerprint"] = i.Fingerprint
+	}
+	return details
+}
+
+// setBanner splits an identification line such as
+// "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3" into its parts.
+func (i *SSHInfo) setBanner(line string) {
+	i.Banner = line
+	parts := strings.SplitN(line, "-", 3)
+	if len(parts) < 3 {
+		return
+	}
+	i.Protocol = parts[1]
+	i.Software, i.Comments, _ = strings.Cut(parts[2], " ")
+}
+
+// CheckSSH connects to host:port, reads the server's banner and runs the
+// key exchange until the server has proven its host key. It returns the
+// time from connecting to receiving the host key. The returned info is
+// never nil and reports the banner even when the key exchange fails.
+func CheckSSH(ctx context.Context, host string, port int, timeout time.Duration) (*SSHInfo, time.Duration, error) {
+	info := &SSHInfo{}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	start := time.Now()
+	raw, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return info, time.Since(start), err
+	}
+	defer raw.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		raw.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { raw.SetDeadline(time.Now()) })
+	defer stop()
+
+	var hostKey ssh.PublicKey
+	conn := &preambleConn{Conn: raw}
+	_, _, _, err = ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User:              "nexa",
+		HostKeyAlgorithms: sshHostKeyAlgorithms,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyReceived
+		},
+	})
+	latency := time.Since(start)
+	if banner := conn.banner(); banner != "" {
+		info.setBanner(banner)
+	}
+	if hostKey == nil {
+		return info, latency, sshError(ctx, conn, address, err)
+	}
+	info.HostKeyType = hostKey.Type()
+	info.Fingerprint = ssh.FingerprintSHA256(hostKey)
+	return info, latency, nil
+}
+
+// sshError keeps network errors classifiable; the ssh package only
+// reports them as text.
+func sshError(ctx context.Context, conn *preambleConn, address string, err error) error {
+	switch {
+	case ctx.Err() != nil && errors.Is(ctx.Err(), context.Canceled):
+		return ctx.Err()
+	case conn.err != nil && !errors.Is(conn.err, io.EOF):
+		return conn.err
+	case conn.banner() == "":
+		return newProbeError(ErrorClassSSHHandshake, fmt.Errorf("no SSH banner from %s", address))
+	}
+	return newProbeError(ErrorClassSSHHandshake, err)
+}
+
+// preambleConn records the first bytes read from the server and the
+// first read error.
+type preambleConn struct {
+	net.Conn
+	preamble []byte
+	err      error
+}
+
+func (c *preambleConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if room := maxSSHPreamble - len(c.preamble); room > 0 {
+		c.preamble = append(c.preamble, p[:min(n, room)]...)
+	}
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// banner returns the server's identification line. Servers may send
+// other lines before it.
+func (c *preambleConn) banner() string {
+	for _, line := range strings.Split(string(c.preamble), "\n") {
+		if strings.HasPrefix(line, "SSH-") {
+			return strings.TrimRight(line, "\r")
+		}
+	}
+	return ""
+}
+
+// matchHostKey reports whether fingerprint is one of expected, which may
+// omit the SHA256: prefix and base64 padding.
+func matchHostKey(fingerprint string, expected []string) bool {
+	for _, want := range expected {
+		if normalizeHostKey(want) == normalizeHostKey(fingerprint) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHostKey(fingerprint string) string {
+	return strings.TrimRight(strings.TrimPrefix(strings.TrimSpace(fingerprint), "SHA256:"), "=")
+}
+
+func validateHostKeyFingerprints(fingerprints []string) error {
+	for _, fingerprint := range fingerprints {
+		sum, err := base64.RawStdEncoding.DecodeString(normalizeHostKey(fingerprint))
+		if err != nil || len(sum) != 32 {
+			return fmt.Errorf("invalid host key fingerprint %q: expected SHA256:<base64>", fingerprint)
+		}
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,133 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// newSSHServer runs an SSH server with a fresh ed25519 host key and
+// returns its target and host key fingerprint.
+func newSSHServer(t *testing.T) (config.HostPort, string) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ssh.ServerConfig{
+		NoClientAuth:  true,
+		ServerVersion: "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3",
+	}
+	serverConfig.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				ssh.NewServerConn(conn, serverConfig)
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}, ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+// newSMTPGreeter reads the client's first line, answers like an SMTP
+// server and hangs up.
+func newSMTPGreeter(t *testing.T) config.HostPort {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte("220 mail.corp.local ESMTP\r\n"))
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}
+}
+
+func TestSSHProbe(t *testing.T) {
+	server, fingerprint := newSSHServer(t)
+	other, _ := newSSHServer(t)
+	probe := newSSHProbe(&config.Config{SSHTimeout: 2 * time.Second})
+
+	tests := []struct {
+		name         string
+		target       config.HostPort
+		fingerprints []string
+		class        ErrorClass
+	}{
+		{"no fingerprint", server, nil, ""},
+		{"matching fingerprint", server, []string{"SHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", fingerprint}, ""},
+		{"fingerprint without prefix", server, []string{strings.TrimPrefix(fingerprint, "SHA256:")}, ""},
+		{"re-provisioned host", other, []string{fingerprint}, ErrorClassSSHHostKey},
+		{"not ssh", newSMTPGreeter(t), nil, ErrorClassSSHHandshake},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := tt.target
+			hp.HostKeyFingerprints = tt.fingerprints
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if tt.class == ErrorClassSSHHandshake {
+				return
+			}
+			if result.checkDetails["ssh_version"] != "OpenSSH_9.6p1" {
+				t.Errorf("Expected ssh_version for the check details, got %v", result.checkDetails)
+			}
+			if result.Details["software_version"] != "OpenSSH_9.6p1" || result.Details["comments"] != "Ubuntu-3" {
+				t.Errorf("Expected the server version, got %v", result.Details)
+			}
+			if result.Details["host_key_type"] != ssh.KeyAlgoED25519 {
+				t.Errorf("Expected an ed25519 host key, got %v", result.Details["host_key_type"])
+			}
+		})
+	}
+}
+
+func TestNexaInvalidHostKeyFingerprint(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "bastion.corp.local", Port: 22, Probes: []string{"ssh"}, HostKeyFingerprints: []string{"MD5:16:27:ac"}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for a non-SHA256 fingerprint")
+	}
+}
\ No newline at end of file
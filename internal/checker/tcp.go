@@ -1,17 +1,225 @@
 package checker
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"strconv"
 	"time"
+
+	"github.com/ferchd/nexa/internal/config"
 )
 
-func CheckTCP(host string, port int, timeout time.Duration) bool {
-	address := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", address, timeout)
+func init() {
+	RegisterProbe("tcp", newTCPProbe)
+}
+
+// maxTCPReply bounds the reply read by a tcp conversation.
+const maxTCPReply = 64 * 1024
+
+type tcpProbe struct {
+	timeout time.Duration
+	caFile  string
+}
+
+func newTCPProbe(cfg *config.Config) Probe {
+	return &tcpProbe{timeout: cfg.TCPTimeout, caFile: cfg.TLSCAFile}
+}
+
+func (p *tcpProbe) Name() string {
+	return "tcp"
+}
+
+func (p *tcpProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if target.Port <= 0 {
+		return newProbeResult(0, newProbeError(ErrorClassConfig,
+			fmt.Errorf("no port configured for %s", target.Host)))
+	}
+	if !isConversation(target.TCP) {
+		return newProbeResult(CheckTCP(ctx, target.Host, target.Port, target.timeout(p.timeout)))
+	}
+
+	payload, err := exchangePayload(target.TCP.Exchange)
+	if err != nil {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+	}
+	var tlsOpts *TLSOptions
+	if target.TCP.TLS {
+		roots, err := targetRootCAs(target, p.caFile)
+		if err != nil {
+			return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+		}
+		tlsOpts = &TLSOptions{ServerName: target.SNI, RootCAs: roots, ALPN: target.ALPN}
+		if tlsOpts.ServerName == "" {
+			tlsOpts.ServerName = target.Host
+		}
+	}
+
+	conv, err := CheckTCPConversation(ctx, target.Host, target.Port, payload, target.TCP, tlsOpts, target.timeout(p.timeout))
+	result := newProbeResult(conv.Connect+conv.Response, err)
+	result.Details = conv.details()
+	return result
+}
+
+// isConversation reports whether opts asks for more than a connect.
+func isConversation(opts config.TCPOptions) bool {
+	return opts.TLS || opts.Send != "" || opts.SendHex != "" || opts.ReadBytes > 0 || expectsReply(opts.Exchange)
+}
+
+// CheckTCP connects to host:port and returns the connect time.
+func CheckTCP(ctx context.Context, host string, port int, timeout time.Duration) (time.Duration, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	latency := time.Since(start)
 	if err != nil {
-		return false
+		return latency, err
 	}
 	defer conn.Close()
-	return true
+	return latency, nil
+}
+
+// TCPConversation describes a tcp conversation. Connect includes the TLS
+// handshake and Response runs from sending the payload to the end of the
+// reply.
+type TCPConversation struct {
+	Connect    time.Duration
+	Response   time.Duration
+	Sent       int
+	Reply      []byte
+	TLSVersion string
+}
+
+func (c *TCPConversation) details() map[string]interface{} {
+	details := map[string]interface{}{
+		"connect_ms":  durationMs(c.Connect),
+		"response_ms": durationMs(c.Response),
+		"sent_bytes":  c.Sent,
+		"reply_bytes": len(c.Reply),
+	}
+	if len(c.Reply) > 0 {
+		details["reply"] = replyPreview(c.Reply)
+	}
+	if c.TLSVersion != "" {
+		details["tls_version"] = c.TLSVersion
+	}
+	return details
+}
+
+// CheckTCPConversation connects to host:port, upgrades the connection to
+// TLS when tlsOpts is set, sends payload and reads the reply as opts
+// requires. The whole conversation must fit in timeout. The returned
+// conversation is never nil.
+func CheckTCPConversation(ctx context.Context, host string, port int, payload []byte, opts config.TCPOptions, tlsOpts *TLSOptions, timeout time.Duration) (*TCPConversation, error) {
+	conv := &TCPConversation{}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		conv.Connect = time.Since(start)
+		return conv, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if tlsOpts != nil {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName: tlsOpts.ServerName,
+			RootCAs:    tlsOpts.RootCAs,
+			NextProtos: tlsOpts.ALPN,
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conv.Connect = time.Since(start)
+			return conv, err
+		}
+		conv.TLSVersion = tls.VersionName(tlsConn.ConnectionState().Version)
+		conn = tlsConn
+	}
+	conv.Connect = time.Since(start)
+
+	start = time.Now()
+	if len(payload) > 0 {
+		n, err := conn.Write(payload)
+		conv.Sent = n
+		if err != nil {
+			conv.Response = time.Since(start)
+			return conv, conversationError(ctx, address, err)
+		}
+	}
+	if opts.ReadBytes == 0 && !expectsReply(opts.Exchange) {
+		conv.Response = time.Since(start)
+		return conv, nil
+	}
+
+	reply, err := readReply(conn, opts)
+	conv.Response = time.Since(start)
+	conv.Reply = reply
+	if len(reply) == 0 && err != nil {
+		return conv, conversationError(ctx, address, err)
+	}
+	if err := verifyReply(reply, opts.Exchange); err != nil {
+		return conv, err
+	}
+	if opts.ReadBytes > 0 && len(reply) < opts.ReadBytes {
+		return conv, replyMismatch("read %d of %d bytes", len(reply), opts.ReadBytes)
+	}
+	return conv, nil
+}
+
+// readReply reads read_bytes bytes or, without read_bytes, until the reply
+// meets the expectations. It stops early at maxTCPReply bytes and on
+// errors, including the deadline.
+func readReply(conn net.Conn, opts config.TCPOptions) ([]byte, error) {
+	var reply []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if opts.ReadBytes > 0 && len(reply) >= opts.ReadBytes {
+			return reply[:opts.ReadBytes], nil
+		}
+		if opts.ReadBytes == 0 && verifyReply(reply, opts.Exchange) == nil {
+			return reply, nil
+		}
+		if err != nil || len(reply) >= maxTCPReply {
+			return reply, err
+		}
+	}
+}
+
+// conversationError reports a peer that closes the connection or stays
+// silent as no_reply.
+func conversationError(ctx context.Context, address string, err error) error {
+	switch {
+	case ctx.Err() != nil && errors.Is(ctx.Err(), context.Canceled):
+		return ctx.Err()
+	case errors.Is(err, io.EOF):
+		return newProbeError(ErrorClassNoReply, fmt.Errorf("%s closed the connection without a reply", address))
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return newProbeError(ErrorClassNoReply, fmt.Errorf("no reply from %s", address))
+	}
+	return err
+}
+
+func validateTCPOptions(opts config.TCPOptions) error {
+	if opts.ReadBytes < 0 || opts.ReadBytes > maxTCPReply {
+		return fmt.Errorf("read_bytes must be between 0 and %d, got %d", maxTCPReply, opts.ReadBytes)
+	}
+	return validateExchange(opts.Exchange)
 }
\ No newline at end of file
@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// serveLines greets with a banner and answers PING with +PONG on every
+// connection accepted by ln.
+func serveLines(t *testing.T, ln net.Listener) config.HostPort {
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("220 ready\r\n"))
+				lines := bufio.NewScanner(conn)
+				for lines.Scan() {
+					if lines.Text() == "PING" {
+						conn.Write([]byte("+PONG\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}
+}
+
+func newLineServer(t *testing.T) config.HostPort {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveLines(t, ln)
+}
+
+// newTLSLineServer is newLineServer over TLS with the httptest certificate,
+// and returns a CA bundle for it.
+func newTLSLineServer(t *testing.T) (config.HostPort, string) {
+	_, caFile := newTLSTarget(t)
+	stub := httptest.NewTLSServer(http.NotFoundHandler())
+	certs := stub.TLS.Certificates
+	stub.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveLines(t, ln), caFile
+}
+
+func TestTCPConversation(t *testing.T) {
+	plain := newLineServer(t)
+	secure, caFile := newTLSLineServer(t)
+	addr, cleanup := MockTCPServer(t)
+	defer cleanup()
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	halfOpen := config.HostPort{Host: host, Port: portNum}
+	probe := newTCPProbe(&config.Config{TCPTimeout: 300 * time.Millisecond})
+
+	tests := []struct {
+		name   string
+		target config.HostPort
+		opts   config.TCPOptions
+		caFile string
+		class  ErrorClass
+		reply  string
+	}{
+		{"banner", plain, config.TCPOptions{Exchange: config.Exchange{Expect: "220"}}, "", "", "220 ready\r\n"},
+		{"send and expect", plain, config.TCPOptions{Exchange: config.Exchange{Send: "PING\r\n", ExpectRegex: `\+PONG\r\n$`}}, "", "", "220 ready\r\n+PONG\r\n"},
+		{"byte count", plain, config.TCPOptions{ReadBytes: 3}, "", "", "220"},
+		{"unexpected reply", plain, config.TCPOptions{Exchange: config.Exchange{Send: "PING\r\n", Expect: "OK"}}, "", ErrorClassReplyMismatch, "220 ready\r\n+PONG\r\n"},
+		{"short reply", plain, config.TCPOptions{ReadBytes: 64}, "", ErrorClassReplyMismatch, "220 ready\r\n"},
+		{"half-open", halfOpen, config.TCPOptions{Exchange: config.Exchange{Expect: "220"}}, "", ErrorClassNoReply, ""},
+		{"tls", secure, config.TCPOptions{TLS: true, Exchange: config.Exchange{Send: "PING\r\n", Expect: "+PONG"}}, caFile, "", "220 ready\r\n+PONG\r\n"},
+		{"tls untrusted", secure, config.TCPOptions{TLS: true, Exchange: config.Exchange{Expect: "220"}}, "", ErrorClassTLSHandshake, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := tt.target
+			hp.TCP = tt.opts
+			hp.CAFile = tt.caFile
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if reply, _ := result.Details["reply"].(string); reply != tt.reply {
+				t.Errorf("Expected reply %q, got %q", tt.reply, reply)
+			}
+			if tt.opts.TLS && tt.class == "" && result.Details["tls_version"] == nil {
+				t.Errorf("Expected the TLS version to be reported, got %v", result.Details)
+			}
+		})
+	}
+}
+
+func TestNexaInvalidTCPOptions(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "smtp.corp.local", Port: 25, Probes: []string{"tcp"}, TCP: config.TCPOptions{ReadBytes: -1}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for a negative read_bytes")
+	}
+}
\ No newline at end of file
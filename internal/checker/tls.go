@@ -0,0 +1,232 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+	"github.com/ferchd/nexa/internal/metrics"
+)
+
+func init() {
+	RegisterProbe("tls", newTLSProbe)
+}
+
+// DefaultTLSPort is used by the tls probe for targets without a port.
+const DefaultTLSPort = 443
+
+// defaultALPN is offered when a target does not configure alpn.
+var defaultALPN = []string{"h2", "http/1.1"}
+
+type tlsProbe struct {
+	timeout time.Duration
+	caFile  string
+}
+
+func newTLSProbe(cfg *config.Config) Probe {
+	return &tlsProbe{timeout: cfg.TLSTimeout, caFile: cfg.TLSCAFile}
+}
+
+func (p *tlsProbe) Name() string {
+	return "tls"
+}
+
+func (p *tlsProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	opts, err := p.options(target)
+	if err != nil {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+	}
+
+	port := target.Port
+	if port <= 0 {
+		port = DefaultTLSPort
+	}
+
+	info, latency, err := CheckTLS(ctx, target.Host, port, opts, target.timeout(p.timeout))
+	// A failed handshake keeps its own error; pins only judge chains that
+	// verified.
+	pins := pinsFor(target)
+	pinned := err == nil && pins.configured()
+	if pinned {
+		if mismatch := pins.check(info.Verified); mismatch != nil {
+			err = newProbeError(ErrorClassTLSIntercepted, mismatch)
+		}
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = info.details()
+	if info.Leaf() != nil {
+		days := info.DaysUntilExpiry()
+		result.record = func(m *metrics.PrometheusMetrics, target string) {
+			m.UpdateCertExpiry(target, days)
+		}
+	}
+	if pinned {
+		result.Details["tls_intercepted"] = err != nil
+	}
+	return result
+}
+
+func (p *tlsProbe) options(target Target) (TLSOptions, error) {
+	opts := TLSOptions{ServerName: target.SNI, ALPN: target.ALPN}
+	if opts.ServerName == "" {
+		opts.ServerName = target.Host
+	}
+	if opts.ALPN == nil {
+		opts.ALPN = defaultALPN
+	}
+
+	pool, err := targetRootCAs(target, p.caFile)
+	opts.RootCAs = pool
+	return opts, err
+}
+
+// targetRootCAs loads the target's ca_file, or caFile when the target has
+// none. It returns nil to use the system pool.
+func targetRootCAs(target Target, caFile string) (*x509.CertPool, error) {
+	if target.CAFile != "" {
+		caFile = target.CAFile
+	}
+	if caFile == "" {
+		return nil, nil
+	}
+	return loadCAFile(caFile)
+}
+
+// TLSOptions controls a TLS handshake. A nil RootCAs uses the system pool.
+type TLSOptions struct {
+	ServerName string
+	RootCAs    *x509.CertPool
+	ALPN       []string
+}
+
+// TLSInfo describes the connection state and certificate chain presented
+// by a server. Verified holds the chains built from it up to a trusted
+// root; it is empty when verification failed.
+type TLSInfo struct {
+	Version     string
+	CipherSuite string
+	ALPN        string
+	ServerName  string
+	Chain       []*x509.Certificate
+	Verified    [][]*x509.Certificate
+}
+
+// Leaf returns the server certificate.
+func (i *TLSInfo) Leaf() *x509.Certificate {
+	if len(i.Chain) == 0 {
+		return nil
+	}
+	return i.Chain[0]
+}
+
+// DaysUntilExpiry returns the days left before the server certificate
+// expires; it is negative for expired certificates.
+func (i *TLSInfo) DaysUntilExpiry() float64 {
+	leaf := i.Leaf()
+	if leaf == nil {
+		return 0
+	}
+	return time.Until(leaf.NotAfter).Hours() / 24
+}
+
+func (i *TLSInfo) details() map[string]interface{} {
+	details := map[string]interface{}{
+		"sni": i.ServerName,
+	}
+	if i.Version != "" {
+		details["tls_version"] = i.Version
+		details["cipher_suite"] = i.CipherSuite
+		details["alpn"] = i.ALPN
+	}
+	if leaf := i.Leaf(); leaf != nil {
+		details["subject"] = leaf.Subject.String()
+		details["issuer"] = leaf.Issuer.String()
+		details["not_after"] = leaf.NotAfter
+		details["days_until_expiry"] = i.DaysUntilExpiry()
+		details["spki_sha256"] = spkiFingerprint(leaf)
+		details["cert_sha256"] = certFingerprint(leaf)
+	}
+	return details
+}
+
+// CheckTLS connects to host:port, performs a TLS handshake and verifies the
+// presented chain against opts.RootCAs for opts.ServerName. It returns the
+// connect plus handshake time. The certificate chain is reported even when
+// verification fails.
+func CheckTLS(ctx context.Context, host string, port int, opts TLSOptions, timeout time.Duration) (*TLSInfo, time.Duration, error) {
+	info := &TLSInfo{ServerName: opts.ServerName}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config: &tls.Config{
+			ServerName: opts.ServerName,
+			NextProtos: opts.ALPN,
+			// The chain is verified in VerifyConnection so that it is
+			// available to the caller even when verification fails.
+			InsecureSkipVerify: true,
+			VerifyConnection: func(state tls.ConnectionState) error {
+				info.Chain = state.PeerCertificates
+				chains, err := verifyChain(state.PeerCertificates, opts)
+				info.Verified = chains
+				return err
+			},
+		},
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	latency := time.Since(start)
+	if err != nil {
+		return info, latency, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	info.Version = tls.VersionName(state.Version)
+	info.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	info.ALPN = state.NegotiatedProtocol
+	return info, latency, nil
+}
+
+// verifyChain verifies the presented certificates for opts.ServerName and
+// returns the chains that lead to a trusted root.
+func verifyChain(certs []*x509.Certificate, opts TLSOptions) ([][]*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, newProbeError(ErrorClassTLSHandshake, errors.New("tls: server presented no certificates"))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       opts.ServerName,
+		Roots:         opts.RootCAs,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return nil, &tls.CertificateVerificationError{UnverifiedCertificates: certs, Err: err}
+	}
+	return chains, nil
+}
+
+// loadCAFile reads a PEM bundle into a certificate pool.
+func loadCAFile(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
\ No newline at end of file
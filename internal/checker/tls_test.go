@@ -0,0 +1,345 @@
+package checker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// newTLSTarget starts an HTTPS server with HTTP/2 enabled and writes its
+// certificate to a CA bundle.
+func newTLSTarget(t *testing.T) (config.HostPort, string) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}, caFile
+}
+
+func TestTLSProbe(t *testing.T) {
+	target, caFile := newTLSTarget(t)
+	probe := newTLSProbe(&config.Config{TLSTimeout: 2 * time.Second})
+
+	tests := []struct {
+		name    string
+		sni     string
+		caFile  string
+		success bool
+	}{
+		{"custom CA", "example.com", caFile, true},
+		{"system CA", "example.com", "", false},
+		{"wrong SNI", "other.example", caFile, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := target
+			hp.SNI = tt.sni
+			hp.CAFile = tt.caFile
+
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.Success != tt.success {
+				t.Fatalf("Expected success=%v, got %v (%s)", tt.success, result.Success, result.Error)
+			}
+			if !tt.success && result.ErrorClass != ErrorClassTLSHandshake {
+				t.Errorf("Expected error class %s, got %s", ErrorClassTLSHandshake, result.ErrorClass)
+			}
+			if _, ok := result.Details["days_until_expiry"].(float64); !ok {
+				t.Errorf("Expected days_until_expiry in details, got %v", result.Details)
+			}
+			if tt.success && (result.Details["alpn"] != "h2" || result.Details["tls_version"] == "") {
+				t.Errorf("Expected negotiated h2 and a TLS version, got %v", result.Details)
+			}
+		})
+	}
+}
+
+func TestTLSProbe_MissingCAFile(t *testing.T) {
+	probe := newTLSProbe(&config.Config{TLSCAFile: "/nonexistent/ca.pem"})
+	result := probe.Execute(context.Background(), Target{HostPort: config.HostPort{Host: "127.0.0.1"}})
+	if result.Success || result.ErrorClass != ErrorClassConfig {
+		t.Errorf("Expected a config error, got %+v", result)
+	}
+}
+func TestTLSProbe_Pins(t *testing.T) {
+	target, caFile := newTLSTarget(t)
+	probe := newTLSProbe(&config.Config{TLSTimeout: 2 * time.Second, TLSCAFile: caFile})
+	target.SNI = "example.com"
+
+	info, _, err := CheckTLS(context.Background(), target.Host, target.Port, TLSOptions{ServerName: "example.com"}, 2*time.Second)
+	if info.Leaf() == nil {
+		t.Fatalf("Expected the server certificate, got error %v", err)
+	}
+	fingerprint := certFingerprint(info.Leaf())
+	wrongPin := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name        string
+		spki        []string
+		certs       []string
+		issuer      string
+		intercepted bool
+	}{
+		{"cert fingerprint", nil, []string{fingerprint}, "", false},
+		{"spki pin", []string{spkiFingerprint(info.Leaf())}, nil, "", false},
+		{"expected issuer", nil, nil, "O=Acme Co", false},
+		{"issuer substring", nil, nil, "Acme", true},
+		{"wrong pin", []string{wrongPin}, nil, "", true},
+		{"wrong issuer", nil, nil, "Example Root CA", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := target
+			hp.SPKIPins, hp.CertFingerprints, hp.ExpectIssuer = tt.spki, tt.certs, tt.issuer
+
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.Details["tls_intercepted"] != tt.intercepted {
+				t.Fatalf("Expected tls_intercepted=%v, got %v (%s)", tt.intercepted, result.Details["tls_intercepted"], result.Error)
+			}
+			if tt.intercepted && (result.Success || result.ErrorClass != ErrorClassTLSIntercepted) {
+				t.Errorf("Expected a %s failure, got %+v", ErrorClassTLSIntercepted, result)
+			}
+			if !tt.intercepted && !result.Success {
+				t.Errorf("Expected success, got %s", result.Error)
+			}
+		})
+	}
+}
+
+// testCA is a certificate authority for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate for dnsName signed by ca, followed by extra
+// certificates in the presented chain.
+func (ca *testCA) issue(t *testing.T, dnsName string, extra ...*x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	for _, cert := range extra {
+		chain.Certificate = append(chain.Certificate, cert.Raw)
+	}
+	return chain
+}
+
+// bundle writes the CA certificate to a PEM file.
+func (ca *testCA) bundle(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newChainServer serves TLS handshakes with cert.
+func newChainServer(t *testing.T, cert tls.Certificate) config.HostPort {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum, SNI: "portal.corp.local"}
+}
+
+// TestTLSProbe_PinnedChain checks that a proxy trusted through the CA
+// bundle cannot satisfy a CA pin by appending the genuine CA to its forged
+// chain, and that a genuine server failing verification is not reported
+// as intercepted.
+func TestTLSProbe_PinnedChain(t *testing.T) {
+	genuine := newTestCA(t, "Corp Root CA")
+	proxy := newTestCA(t, "Proxy CA")
+	pin := spkiFingerprint(genuine.cert)
+	genuineServer := newChainServer(t, genuine.issue(t, "portal.corp.local"))
+
+	tests := []struct {
+		name        string
+		target      config.HostPort
+		caFile      string
+		sni         string
+		intercepted bool
+		class       ErrorClass
+	}{
+		{"genuine chain", genuineServer, genuine.bundle(t), "", false, ""},
+		{"forged chain", newChainServer(t, proxy.issue(t, "portal.corp.local", genuine.cert)), proxy.bundle(t), "", true, ErrorClassTLSIntercepted},
+		{"untrusted genuine chain", genuineServer, proxy.bundle(t), "", false, ErrorClassTLSHandshake},
+		{"hostname mismatch", genuineServer, genuine.bundle(t), "wiki.corp.local", false, ErrorClassTLSHandshake},
+	}
+
+	probe := newTLSProbe(&config.Config{TLSTimeout: 2 * time.Second})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := tt.target
+			hp.CAFile = tt.caFile
+			hp.SPKIPins = []string{pin}
+			if tt.sni != "" {
+				hp.SNI = tt.sni
+			}
+
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if intercepted, _ := result.Details["tls_intercepted"].(bool); intercepted != tt.intercepted {
+				t.Fatalf("Expected tls_intercepted=%v, got %v (%s)", tt.intercepted, result.Details["tls_intercepted"], result.Error)
+			}
+			if result.ErrorClass != tt.class {
+				t.Errorf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+		})
+	}
+}
+
+func TestNexaTLSIntercepted(t *testing.T) {
+	target, caFile := newTLSTarget(t)
+	target.Probes = []string{"tls"}
+	target.SNI = "example.com"
+	target.ExpectIssuer = "Example Root CA"
+
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{target},
+		TLSTimeout:    2 * time.Second,
+		TLSCAFile:     caFile,
+		Attempts:      1,
+	}
+
+	checker, err := NewNexa(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Nexa: %v", err)
+	}
+
+	result := checker.Run()
+	if !result.TLSIntercepted || result.ObservedIssuer != "O=Acme Co" {
+		t.Errorf("Expected interception by O=Acme Co, got %v %q", result.TLSIntercepted, result.ObservedIssuer)
+	}
+}
+
+func TestHTTPProbe_Pins(t *testing.T) {
+	target, caFile := newTLSTarget(t)
+	info, _, _ := CheckTLS(context.Background(), target.Host, target.Port, TLSOptions{ServerName: "example.com"}, 2*time.Second)
+	url := "https://" + net.JoinHostPort(target.Host, strconv.Itoa(target.Port)) + "/"
+	probe := newHTTPProbe(&config.Config{HTTPTimeout: 2 * time.Second, TLSCAFile: caFile})
+
+	tests := []struct {
+		name        string
+		spki        []string
+		issuer      string
+		intercepted bool
+	}{
+		{"spki pin", []string{spkiFingerprint(info.Leaf())}, "", false},
+		{"wrong pin", []string{"sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32))}, "", true},
+		{"wrong issuer", nil, "Example Root CA", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := target
+			hp.HTTPURL = url
+			hp.SPKIPins, hp.ExpectIssuer = tt.spki, tt.issuer
+
+			result := probe.Execute(context.Background(), Target{HostPort: hp})
+			if result.Details["tls_intercepted"] != tt.intercepted {
+				t.Fatalf("Expected tls_intercepted=%v, got %v (%s)", tt.intercepted, result.Details["tls_intercepted"], result.Error)
+			}
+			if tt.intercepted && result.ErrorClass != ErrorClassTLSIntercepted {
+				t.Errorf("Expected a %s failure, got %+v", ErrorClassTLSIntercepted, result)
+			}
+			if !tt.intercepted && !result.Success {
+				t.Errorf("Expected success, got %s", result.Error)
+			}
+		})
+	}
+}
+
+func TestNexaUncheckedPin(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{{Host: "example.com", Port: 443, Probes: []string{"tcp"}, ExpectIssuer: "WR2"}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for pins on a target without a tls or http probe")
+	}
+}
+
+func TestNexaInvalidPin(t *testing.T) {
+	cfg := &config.Config{
+		ExternalHosts: []config.HostPort{{Host: "example.com", Probes: []string{"tls"}, SPKIPins: []string{"not-a-pin"}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an invalid pin")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,103 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+func init() {
+	RegisterProbe("udp", newUDPProbe)
+}
+
+// maxDatagram is the largest UDP reply that is read.
+const maxDatagram = 65535
+
+type udpProbe struct {
+	timeout time.Duration
+}
+
+func newUDPProbe(cfg *config.Config) Probe {
+	return &udpProbe{timeout: cfg.UDPTimeout}
+}
+
+func (p *udpProbe) Name() string {
+	return "udp"
+}
+
+func (p *udpProbe) Execute(ctx context.Context, target Target) ProbeResult {
+	if target.Port <= 0 {
+		return newProbeResult(0, newProbeError(ErrorClassConfig,
+			fmt.Errorf("no port configured for %s", target.Host)))
+	}
+	payload, err := exchangePayload(target.UDP)
+	if err != nil {
+		return newProbeResult(0, newProbeError(ErrorClassConfig, err))
+	}
+
+	reply, latency, err := CheckUDP(ctx, target.Host, target.Port, payload, target.timeout(p.timeout))
+	if err == nil {
+		err = verifyReply(reply, target.UDP)
+	}
+
+	result := newProbeResult(latency, err)
+	result.Details = map[string]interface{}{"sent_bytes": len(payload)}
+	if reply != nil {
+		result.Details["reply_bytes"] = len(reply)
+		result.Details["reply"] = replyPreview(reply)
+	}
+	return result
+}
+
+// CheckUDP sends payload to host:port and waits for one reply datagram. It
+// returns the reply and the time from sending to receiving it. An ICMP
+// port-unreachable answer fails with port_unreachable and silence with
+// no_reply.
+func CheckUDP(ctx context.Context, host string, port int, payload []byte, timeout time.Duration) ([]byte, time.Duration, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	start := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		return nil, time.Since(start), udpError(ctx, address, err)
+	}
+	buf := make([]byte, maxDatagram)
+	n, err := conn.Read(buf)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, latency, udpError(ctx, address, err)
+	}
+	return buf[:n], latency, nil
+}
+
+// udpError classifies errors on a connected UDP socket, where the kernel
+// reports an ICMP port-unreachable as ECONNREFUSED.
+func udpError(ctx context.Context, address string, err error) error {
+	switch {
+	case ctx.Err() != nil && errors.Is(ctx.Err(), context.Canceled):
+		return ctx.Err()
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return newProbeError(ErrorClassPortUnreachable, fmt.Errorf("%s: port unreachable", address))
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return newProbeError(ErrorClassNoReply, fmt.Errorf("no reply from %s", address))
+	}
+	return err
+}
\ No newline at end of file
@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ferchd/nexa/internal/config"
+)
+
+// newUDPEcho answers "ping" with "pong" and ignores everything else.
+func newUDPEcho(t *testing.T) config.HostPort {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if bytes.Equal(buf[:n], []byte("ping")) {
+				pc.WriteTo([]byte("pong\x00\x01"), addr)
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.HostPort{Host: host, Port: portNum}
+}
+
+// closedUDPPort returns a local port nothing listens on.
+func closedUDPPort(t *testing.T) int {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	pc.Close()
+	return port
+}
+
+func TestUDPProbe(t *testing.T) {
+	echo := newUDPEcho(t)
+	probe := newUDPProbe(&config.Config{UDPTimeout: 200 * time.Millisecond})
+
+	tests := []struct {
+		name     string
+		port     int
+		exchange config.Exchange
+		class    ErrorClass
+	}{
+		{"text reply", echo.Port, config.Exchange{Send: "ping", Expect: "pong"}, ""},
+		{"hex payload", echo.Port, config.Exchange{SendHex: "70 69 6e 67", ExpectHex: "0x0001", ExpectRegex: "^po"}, ""},
+		{"unexpected reply", echo.Port, config.Exchange{Send: "ping", Expect: "PONG"}, ErrorClassReplyMismatch},
+		{"no reply", echo.Port, config.Exchange{Send: "hello"}, ErrorClassNoReply},
+		{"port unreachable", closedUDPPort(t), config.Exchange{Send: "ping"}, ErrorClassPortUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := Target{HostPort: config.HostPort{Host: echo.Host, Port: tt.port, UDP: tt.exchange}}
+			result := probe.Execute(context.Background(), target)
+			if result.ErrorClass != tt.class {
+				t.Fatalf("Expected error class %q, got %q (%s)", tt.class, result.ErrorClass, result.Error)
+			}
+			if tt.class == "" && result.Details["reply"] != "706f6e670001" {
+				t.Errorf("Expected a hex preview of the binary reply, got %v", result.Details["reply"])
+			}
+		})
+	}
+}
+
+func TestNexaInvalidUDPPayload(t *testing.T) {
+	cfg := &config.Config{
+		CorpHosts: []config.HostPort{{Host: "syslog.corp.local", Port: 514, Probes: []string{"udp"}, UDP: config.Exchange{SendHex: "zz"}}},
+	}
+	if _, err := NewNexa(cfg); err == nil {
+		t.Fatal("Expected an error for an invalid hex payload")
+	}
+}
\ No newline at end of file
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -11,17 +13,36 @@ type Config struct {
 	ExternalHosts []HostPort `mapstructure:"external_hosts"`
 	CorpHosts     []HostPort `mapstructure:"corp_hosts"`
 	HTTPURL       string     `mapstructure:"http_url"`
-	DNSProbe      string     `mapstructure:"dns_probe"`
+	DNSProbe      DNSProbe   `mapstructure:"dns_probe"`
+	GlobalProbes  []string   `mapstructure:"global_probes"`
+	Groups        []Group    `mapstructure:"groups"`
+
+	CaptivePortal CaptivePortal `mapstructure:"captive_portal"`
+	SplitHorizon  SplitHorizon  `mapstructure:"split_horizon"`
+	DNSIntegrity  DNSIntegrity  `mapstructure:"dns_integrity"`
+	Ping          PingOptions   `mapstructure:"ping"`
+	NTP           NTPOptions    `mapstructure:"ntp"`
 	
 	TCPTimeout  time.Duration `mapstructure:"tcp_timeout"`
 	HTTPTimeout time.Duration `mapstructure:"http_timeout"`
 	PingTimeout time.Duration `mapstructure:"ping_timeout"`
+	TLSTimeout  time.Duration `mapstructure:"tls_timeout"`
+	DNSTimeout  time.Duration `mapstructure:"dns_timeout"`
+	UDPTimeout  time.Duration `mapstructure:"udp_timeout"`
+	SSHTimeout  time.Duration `mapstructure:"ssh_timeout"`
+
+	TLSCAFile string `mapstructure:"tls_ca_file"`
 	
 	Attempts int           `mapstructure:"attempts"`
 	Backoff  time.Duration `mapstructure:"backoff"`
 	
-	Workers    int  `mapstructure:"workers"`
-	StdoutJSON bool `mapstructure:"stdout_json"`
+	Workers     int            `mapstructure:"workers"`
+	MaxPerHost  int            `mapstructure:"max_per_host"`
+	ProbeLimits map[string]int `mapstructure:"probe_limits"`
+	StdoutJSON  bool           `mapstructure:"stdout_json"`
+
+	Serve    bool          `mapstructure:"serve"`
+	Interval time.Duration `mapstructure:"interval"`
 	
 	Prometheus bool `mapstructure:"prometheus"`
 	PromPort   int  `mapstructure:"prom_port"`
@@ -32,9 +53,210 @@ type Config struct {
 	LogMaxBackups int   `mapstructure:"log_max_backups"`
 }
 
+// DefaultInterval is used between runs in serve mode when no interval is set.
+const DefaultInterval = 60 * time.Second
+
+// Daemon reports whether checks should run continuously instead of once.
+func (c *Config) Daemon() bool {
+	return c.Serve || c.Interval > 0
+}
+
+// RunInterval returns the delay between runs in daemon mode.
+func (c *Config) RunInterval() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return DefaultInterval
+}
+
+// HostPort is a single target. Zero-valued overrides fall back to the
+// global settings.
 type HostPort struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
+	Host   string   `mapstructure:"host"`
+	Port   int      `mapstructure:"port"`
+	Probes []string `mapstructure:"probes"`
+	Weight int      `mapstructure:"weight"`
+
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Attempts int           `mapstructure:"attempts"`
+	HTTPURL  string        `mapstructure:"http_url"`
+	DNSName  string        `mapstructure:"dns_name"`
+	HTTP     HTTPOptions   `mapstructure:"http"`
+	DNS      DNSProbe      `mapstructure:"dns"`
+	Ping     PingOptions   `mapstructure:"ping"`
+	TCP      TCPOptions    `mapstructure:"tcp"`
+	UDP      Exchange      `mapstructure:"udp"`
+	NTP      NTPOptions    `mapstructure:"ntp"`
+
+	Scenario []ScenarioStep `mapstructure:"scenario"`
+
+	SNI    string   `mapstructure:"sni"`
+	CAFile string   `mapstructure:"ca_file"`
+	ALPN   []string `mapstructure:"alpn"`
+
+	// Expected certificate identity; a mismatch is reported as TLS
+	// interception.
+	SPKIPins         []string `mapstructure:"spki_pins"`
+	CertFingerprints []string `mapstructure:"cert_fingerprints"`
+	ExpectIssuer     string   `mapstructure:"expect_issuer"`
+
+	// Expected SSH host keys as SHA256 fingerprints.
+	HostKeyFingerprints []string `mapstructure:"host_key_fingerprints"`
+}
+
+// DNSProbe is a DNS query. Without a server the system resolver is used;
+// otherwise the query is sent to server (host or host:port) over protocol,
+// "udp" by default. Type defaults to A, and every value in Expect must be
+// among the answers. URL is the endpoint of the doh probe. In YAML a plain
+// string sets only the name.
+type DNSProbe struct {
+	Name     string   `mapstructure:"name"`
+	Type     string   `mapstructure:"type"`
+	Server   string   `mapstructure:"server"`
+	Protocol string   `mapstructure:"protocol"`
+	URL      string   `mapstructure:"url"`
+	Expect   []string `mapstructure:"expect"`
+}
+
+// Exchange is a payload to send and the reply to expect. Send is text and
+// SendHex hex-encoded bytes; at most one is set. The reply must contain
+// Expect and the bytes of ExpectHex, and match ExpectRegex, when set.
+type Exchange struct {
+	Send        string `mapstructure:"send"`
+	SendHex     string `mapstructure:"send_hex"`
+	Expect      string `mapstructure:"expect"`
+	ExpectHex   string `mapstructure:"expect_hex"`
+	ExpectRegex string `mapstructure:"expect_regex"`
+}
+
+// TCPOptions turns the tcp probe into a conversation. After connecting,
+// and upgrading to TLS when TLS is set, the payload is sent and the reply
+// read until it meets the expectations or, with ReadBytes, until that many
+// bytes arrived.
+type TCPOptions struct {
+	Exchange  `mapstructure:",squash"`
+	ReadBytes int  `mapstructure:"read_bytes"`
+	TLS       bool `mapstructure:"tls"`
+}
+
+// PingOptions controls the ping probe. Count echo requests are sent
+// Interval apart, independently of attempts. Packet loss above
+// DegradedLoss percent marks the target degraded, and loss above MaxLoss
+// percent fails the probe; zero disables either threshold, in which case
+// the probe only fails when no reply arrives. Mode selects unprivileged
+// ICMP, privileged raw sockets or auto-detection; when ICMP is not
+// permitted the Fallback probe, if any, runs instead.
+type PingOptions struct {
+	Count        int           `mapstructure:"count"`
+	Interval     time.Duration `mapstructure:"interval"`
+	DegradedLoss float64       `mapstructure:"degraded_loss"`
+	MaxLoss      float64       `mapstructure:"max_loss"`
+	Mode         string        `mapstructure:"mode"`
+	Fallback     string        `mapstructure:"fallback"`
+	FallbackPort int           `mapstructure:"fallback_port"`
+}
+
+// NTPOptions controls the ntp probe. A clock offset beyond DegradedOffset
+// marks the target degraded and beyond MaxOffset fails the probe; zero
+// disables either threshold.
+type NTPOptions struct {
+	DegradedOffset time.Duration `mapstructure:"degraded_offset"`
+	MaxOffset      time.Duration `mapstructure:"max_offset"`
+}
+
+// DNSIntegrity configures NXDOMAIN rewriting detection: random names under
+// Suffix must not resolve.
+type DNSIntegrity struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Suffix    string   `mapstructure:"suffix"`
+	Resolvers []string `mapstructure:"resolvers"`
+	Samples   int      `mapstructure:"samples"`
+}
+
+// SplitHorizon compares Name as seen by the local Resolvers ("system" for
+// the OS resolver) with the answers of public External resolvers. Internal
+// optionally lists the answers expected inside the network.
+type SplitHorizon struct {
+	Name      string   `mapstructure:"name"`
+	Type      string   `mapstructure:"type"`
+	Protocol  string   `mapstructure:"protocol"`
+	Resolvers []string `mapstructure:"resolvers"`
+	External  []string `mapstructure:"external"`
+	Internal  []string `mapstructure:"internal"`
+}
+
+// HTTPOptions customizes the request sent by the http probe and the
+// response it must produce. Without expect_status any 2xx or 3xx status is
+// accepted; redirects are only followed up to max_redirects.
+type HTTPOptions struct {
+	Method       string            `mapstructure:"method"`
+	Headers      map[string]string `mapstructure:"headers"`
+	Body         string            `mapstructure:"body"`
+	Username     string            `mapstructure:"username"`
+	Password     string            `mapstructure:"password"`
+	BearerToken  string            `mapstructure:"bearer_token"`
+	ExpectStatus []int             `mapstructure:"expect_status"`
+	MaxRedirects int               `mapstructure:"max_redirects"`
+
+	BodyContains  []string          `mapstructure:"body_contains"`
+	BodyMatches   []string          `mapstructure:"body_matches"`
+	JSON          []JSONAssertion   `mapstructure:"json"`
+	HeaderMatches map[string]string `mapstructure:"header_matches"`
+}
+
+// JSONAssertion requires the value at a dotted path of a JSON response,
+// such as "status" or "checks.0.name", to equal Equals.
+type JSONAssertion struct {
+	Path   string `mapstructure:"path"`
+	Equals string `mapstructure:"equals"`
+}
+
+// ScenarioStep is one request of a multi-step HTTP scenario. "{{name}}"
+// placeholders in the URL, headers, body, credentials and assertions are
+// replaced with variables extracted by earlier steps.
+type ScenarioStep struct {
+	Name        string    `mapstructure:"name"`
+	URL         string    `mapstructure:"url"`
+	Extract     []Extract `mapstructure:"extract"`
+	HTTPOptions `mapstructure:",squash"`
+}
+
+// Extract stores a value of a step's response in Var. Exactly one source is
+// set: a JSON path, a regex whose first group is used, or a header name.
+type Extract struct {
+	Var    string `mapstructure:"var"`
+	JSON   string `mapstructure:"json"`
+	Regex  string `mapstructure:"regex"`
+	Header string `mapstructure:"header"`
+}
+
+// Group is a named set of targets whose status is reported on its own.
+// Groups named "external" or "corporate" extend the built-in groups.
+type Group struct {
+	Name     string     `mapstructure:"name"`
+	Hosts    []HostPort `mapstructure:"hosts"`
+	Probes   []string   `mapstructure:"probes"`
+	Policy   string     `mapstructure:"policy"`
+	ExitCode int        `mapstructure:"exit_code"`
+
+	GlobalChecks []string `mapstructure:"global_checks"`
+}
+
+// CaptivePortal configures captive-portal detection. When no URLs are set,
+// http_url is used with an expected 204 response.
+type CaptivePortal struct {
+	Enabled   bool         `mapstructure:"enabled"`
+	URLs      []CaptiveURL `mapstructure:"urls"`
+	Agreement string       `mapstructure:"agreement"`
+}
+
+// CaptiveURL is a detection URL and the response it must return when no
+// portal intercepts the request. ExpectStatus defaults to 204; ExpectBody,
+// when set, must be contained in the response body.
+type CaptiveURL struct {
+	URL          string `mapstructure:"url"`
+	ExpectStatus int    `mapstructure:"expect_status"`
+	ExpectBody   string `mapstructure:"expect_body"`
 }
 
 func Load() (*Config, error) {
@@ -57,27 +279,61 @@ func Load() (*Config, error) {
 
 	parseFlags()
 
+	return decode()
+}
+
+// decode unmarshals the merged viper settings.
+func decode() (*Config, error) {
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		stringToDNSProbe,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := viper.Unmarshal(&cfg, hook); err != nil {
 		return nil, fmt.Errorf("unable to decode config: %v", err)
 	}
 
 	return &cfg, nil
 }
 
+// stringToDNSProbe accepts the plain-name form of a DNS query.
+func stringToDNSProbe(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(DNSProbe{}) {
+		return DNSProbe{Name: data.(string)}, nil
+	}
+	return data, nil
+}
+
 func setDefaults() {
 	viper.SetDefault("external_hosts", []map[string]interface{}{
 		{"host": "8.8.8.8", "port": 53},
 		{"host": "1.1.1.1", "port": 53},
 	})
 	viper.SetDefault("corp_hosts", []map[string]interface{}{})
+	viper.SetDefault("groups", []map[string]interface{}{})
 	viper.SetDefault("http_url", "https://www.google.com/generate_204")
+	viper.SetDefault("captive_portal.enabled", false)
+	viper.SetDefault("captive_portal.agreement", "all")
+	viper.SetDefault("ping.count", 3)
+	viper.SetDefault("ping.interval", time.Second)
+	viper.SetDefault("ping.mode", "auto")
+	viper.SetDefault("ntp.max_offset", 5*time.Minute)
+	viper.SetDefault("dns_integrity.suffix", "example.com")
+	viper.SetDefault("dns_integrity.samples", 3)
 	viper.SetDefault("tcp_timeout", 2*time.Second)
 	viper.SetDefault("http_timeout", 5*time.Second)
 	viper.SetDefault("ping_timeout", 3*time.Second)
+	viper.SetDefault("tls_timeout", 5*time.Second)
+	viper.SetDefault("dns_timeout", 2*time.Second)
+	viper.SetDefault("udp_timeout", 2*time.Second)
+	viper.SetDefault("ssh_timeout", 5*time.Second)
 	viper.SetDefault("attempts", 2)
 	viper.SetDefault("backoff", 1500*time.Millisecond)
 	viper.SetDefault("workers", 8)
+	viper.SetDefault("max_per_host", 0)
+	viper.SetDefault("serve", false)
+	viper.SetDefault("interval", 0)
 	viper.SetDefault("prometheus", false)
 	viper.SetDefault("prom_port", 9000)
 	viper.SetDefault("log_file", "/var/log/nexa.log")
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// baselineConfig is a v1.0.0 configuration without any of the newer
+// sections.
+const baselineConfig = `
+external_hosts:
+  - host: "8.8.8.8"
+    port: 53
+http_url: "https://intranet.corp.local/health"
+dns_probe: "internal.corp.local"
+`
+
+func TestBaselineConfigKeepsHTTPCheck(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	setDefaults()
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader(baselineConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CaptivePortal.Enabled {
+		t.Error("Expected captive portal detection to be opt-in for existing configs")
+	}
+	if cfg.HTTPURL != "https://intranet.corp.local/health" || cfg.DNSProbe.Name != "internal.corp.local" {
+		t.Errorf("Expected the baseline checks to be kept, got %q and %q", cfg.HTTPURL, cfg.DNSProbe.Name)
+	}
+}
+
+// loadFlags decodes the defaults overridden by the command line args.
+func loadFlags(t *testing.T, args ...string) *Config {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	setDefaults()
+
+	flags := pflag.NewFlagSet("nexa", pflag.ContinueOnError)
+	defineFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	bindFlags(flags)
+
+	cfg, err := decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestFlags(t *testing.T) {
+	cfg := loadFlags(t, "--max-per-host", "3", "--workers", "4")
+	if cfg.MaxPerHost != 3 || cfg.Workers != 4 {
+		t.Errorf("Expected max_per_host 3 and workers 4, got %d and %d", cfg.MaxPerHost, cfg.Workers)
+	}
+
+	cfg = loadFlags(t)
+	if cfg.MaxPerHost != 0 || cfg.Workers != 8 {
+		t.Errorf("Expected the defaults without flags, got %d and %d", cfg.MaxPerHost, cfg.Workers)
+	}
+}
+
+func TestTimeoutFlags(t *testing.T) {
+	cfg := loadFlags(t, "--tls-timeout", "9s", "--dns-timeout", "8s", "--udp-timeout", "7s", "--ssh-timeout", "6s")
+	timeouts := map[string]time.Duration{
+		"tls": cfg.TLSTimeout,
+		"dns": cfg.DNSTimeout,
+		"udp": cfg.UDPTimeout,
+		"ssh": cfg.SSHTimeout,
+	}
+	want := map[string]time.Duration{
+		"tls": 9 * time.Second,
+		"dns": 8 * time.Second,
+		"udp": 7 * time.Second,
+		"ssh": 6 * time.Second,
+	}
+	for name, timeout := range timeouts {
+		if timeout != want[name] {
+			t.Errorf("Expected %s timeout %s, got %s", name, want[name], timeout)
+		}
+	}
+}
\ No newline at end of file
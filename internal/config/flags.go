@@ -10,35 +10,58 @@ import (
 )
 
 func parseFlags() {
-	pflag.StringSlice("external", []string{}, 
+	defineFlags(pflag.CommandLine)
+	pflag.Parse()
+	bindFlags(pflag.CommandLine)
+}
+
+// defineFlags adds the command line flags to flags.
+func defineFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("external", []string{}, 
 		"External host or host:port to probe (can repeat). Example: --external 8.8.8.8:53 --external 1.1.1.1")
-	pflag.StringSlice("corp", []string{},
+	flags.StringSlice("corp", []string{},
 		"Corporate host or host:port to probe (can repeat). Example: --corp fileserver.corp.local:445")
-	pflag.String("http-url", "https://www.google.com/generate_204", 
+	flags.String("http-url", "https://www.google.com/generate_204", 
 		"HTTP URL for captive-portal detection")
-	pflag.String("dns-probe", "", 
+	flags.String("dns-probe", "", 
 		"Internal DNS name for corporate indicator")
 
-	pflag.Duration("tcp-timeout", 2*time.Second, "TCP connect timeout")
-	pflag.Duration("http-timeout", 5*time.Second, "HTTP timeout")
-	pflag.Duration("ping-timeout", 3*time.Second, "Ping timeout")
+	flags.Duration("tcp-timeout", 2*time.Second, "TCP connect timeout")
+	flags.Duration("http-timeout", 5*time.Second, "HTTP timeout")
+	flags.Duration("ping-timeout", 3*time.Second, "ICMP reply timeout per request")
+	flags.Duration("tls-timeout", 5*time.Second, "TLS connect and handshake timeout")
+	flags.Duration("dns-timeout", 2*time.Second, "DNS query timeout")
+	flags.Duration("udp-timeout", 2*time.Second, "UDP reply timeout")
+	flags.Duration("ssh-timeout", 5*time.Second, "SSH connect and key exchange timeout")
 
-	pflag.Int("attempts", 2, "Retry attempts per check")
-	pflag.Duration("backoff", 1500*time.Millisecond, "Backoff between retries")
-	pflag.Int("workers", 8, "Worker count for concurrent checks")
+	flags.Int("attempts", 2, "Retry attempts per check")
+	flags.Duration("backoff", 1500*time.Millisecond, "Backoff between retries")
+	flags.Int("workers", 8, "Worker count for concurrent checks")
+	flags.Int("max-per-host", 0, "Maximum concurrent checks against one host (0 = unlimited)")
 
-	pflag.Bool("stdout-json", false, "Print JSON result to stdout")
+	flags.Bool("stdout-json", false, "Print JSON result to stdout")
 
-	pflag.Bool("prometheus", false, "Enable Prometheus exporter")
-	pflag.Int("prom-port", 9000, "Prometheus exporter port")
+	flags.Duration("interval", 0, "Run checks continuously at this interval (0 runs once)")
 
-	pflag.String("log-file", "/var/log/nexa.log", "Log file path")
-	pflag.String("log-level", "info", "Log level (debug, info, warn, error)")
-	pflag.Int("log-max-size-mb", 10, "Maximum log file size in MB")
-	pflag.Int("log-max-backups", 3, "Maximum number of old log files to retain")
+	flags.Bool("prometheus", false, "Enable Prometheus exporter")
+	flags.Int("prom-port", 9000, "Prometheus exporter port")
 
-	pflag.Parse()
-	viper.BindPFlags(pflag.CommandLine)
+	flags.String("log-file", "/var/log/nexa.log", "Log file path")
+	flags.String("log-level", "info", "Log level (debug, info, warn, error)")
+	flags.Int("log-max-size-mb", 10, "Maximum log file size in MB")
+	flags.Int("log-max-backups", 3, "Maximum number of old log files to retain")
+}
+
+// bindFlags binds every flag to its config key, the flag name with
+// underscores instead of dashes, so that --max-per-host sets max_per_host.
+func bindFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		viper.BindPFlag(strings.ReplaceAll(flag.Name, "-", "_"), flag)
+	})
+
+	if flags.Arg(0) == "serve" {
+		viper.Set("serve", true)
+	}
 
 	if externalHosts := viper.GetStringSlice("external"); len(externalHosts) > 0 {
 		parsed := parseHostStrings(externalHosts)
@@ -16,6 +16,20 @@ type PrometheusMetrics struct {
 	checksTotal     *prometheus.GaugeVec
 	checksSuccess   *prometheus.GaugeVec
 	checksFailed    *prometheus.GaugeVec
+	groupUp         *prometheus.GaugeVec
+	probeFailures   *prometheus.CounterVec
+	probeLatency    *prometheus.HistogramVec
+	queueDepth      prometheus.Gauge
+	queueWait       prometheus.Histogram
+	captivePortal   prometheus.Gauge
+	certExpiry      *prometheus.GaugeVec
+	tlsIntercepted  prometheus.Gauge
+	dnsHijacked     prometheus.Gauge
+	pingPackets     *prometheus.GaugeVec
+	pingLoss        *prometheus.GaugeVec
+	pingRTT         *prometheus.GaugeVec
+	ntpOffset       *prometheus.GaugeVec
+	targetDegraded  *prometheus.GaugeVec
 }
 
 func NewPrometheusMetrics(port int) (*PrometheusMetrics, error) {
@@ -44,6 +58,64 @@ func NewPrometheusMetrics(port int) (*PrometheusMetrics, error) {
 			Name: "nexa_checks_failed_total", 
 			Help: "Total number of failed checks",
 		}, []string{"type"}),
+		groupUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_group_up",
+			Help: "Network group reachable (1=up, 0=down)",
+		}, []string{"group"}),
+		probeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nexa_probe_failures_total",
+			Help: "Failed probe executions by group, probe and error class",
+		}, []string{"group", "probe", "error_class"}),
+		probeLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nexa_probe_latency_seconds",
+			Help:    "Latency of successful probes (connect time, RTT, resolution time or TTFB)",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"target", "probe"}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nexa_check_queue_depth",
+			Help: "Checks waiting for a host slot or a free worker",
+		}),
+		queueWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "nexa_check_queue_wait_seconds",
+			Help:    "Time checks spent queued before getting a host slot and a worker",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+		}),
+		captivePortal: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nexa_captive_portal",
+			Help: "Captive portal detected (1=detected, 0=not detected)",
+		}),
+		certExpiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_tls_cert_expiry_days",
+			Help: "Days until the server certificate seen by the tls probe expires",
+		}, []string{"target"}),
+		tlsIntercepted: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nexa_tls_intercepted",
+			Help: "TLS interception detected by a pinned tls or http probe (1=intercepted, 0=not intercepted)",
+		}),
+		dnsHijacked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nexa_dns_hijacked",
+			Help: "NXDOMAIN rewriting detected by the dns_integrity check (1=hijacked, 0=intact)",
+		}),
+		pingPackets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_ping_packets",
+			Help: "Echo requests sent and replies received in the last ping run",
+		}, []string{"target", "direction"}),
+		pingLoss: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_ping_loss_percent",
+			Help: "Packet loss of the last ping run in percent",
+		}, []string{"target"}),
+		pingRTT: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_ping_rtt_seconds",
+			Help: "Round-trip time statistics of the last ping run",
+		}, []string{"target", "stat"}),
+		ntpOffset: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_ntp_offset_seconds",
+			Help: "Clock offset to the NTP server, positive when the local clock is behind",
+		}, []string{"target"}),
+		targetDegraded: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nexa_target_degraded",
+			Help: "Target up but past a degradation threshold (1=degraded, 0=not degraded)",
+		}, []string{"target"}),
 	}
 
 	prometheus.MustRegister(
@@ -53,6 +125,20 @@ func NewPrometheusMetrics(port int) (*PrometheusMetrics, error) {
 		metrics.checksTotal,
 		metrics.checksSuccess,
 		metrics.checksFailed,
+		metrics.groupUp,
+		metrics.probeFailures,
+		metrics.probeLatency,
+		metrics.queueDepth,
+		metrics.queueWait,
+		metrics.captivePortal,
+		metrics.certExpiry,
+		metrics.tlsIntercepted,
+		metrics.dnsHijacked,
+		metrics.pingPackets,
+		metrics.pingLoss,
+		metrics.pingRTT,
+		metrics.ntpOffset,
+		metrics.targetDegraded,
 	)
 
 	go func() {
@@ -83,6 +169,81 @@ func (m *PrometheusMetrics) UpdateCorporateStatus(up bool) {
 	}
 }
 
+func (m *PrometheusMetrics) UpdateCaptivePortal(detected bool) {
+	if detected {
+		m.captivePortal.Set(1)
+	} else {
+		m.captivePortal.Set(0)
+	}
+}
+
+func (m *PrometheusMetrics) UpdateCertExpiry(target string, days float64) {
+	m.certExpiry.WithLabelValues(target).Set(days)
+}
+
+func (m *PrometheusMetrics) UpdateTLSIntercepted(intercepted bool) {
+	if intercepted {
+		m.tlsIntercepted.Set(1)
+	} else {
+		m.tlsIntercepted.Set(0)
+	}
+}
+
+func (m *PrometheusMetrics) UpdateDNSHijacked(hijacked bool) {
+	if hijacked {
+		m.dnsHijacked.Set(1)
+	} else {
+		m.dnsHijacked.Set(0)
+	}
+}
+
+// UpdatePingStats records the last ping run against target; rttMs maps
+// statistic names such as "avg" or "jitter" to milliseconds.
+func (m *PrometheusMetrics) UpdatePingStats(target string, sent, received int, lossPercent float64, rttMs map[string]float64) {
+	m.pingPackets.WithLabelValues(target, "sent").Set(float64(sent))
+	m.pingPackets.WithLabelValues(target, "received").Set(float64(received))
+	m.pingLoss.WithLabelValues(target).Set(lossPercent)
+	for stat, ms := range rttMs {
+		m.pingRTT.WithLabelValues(target, stat).Set(ms / 1000)
+	}
+}
+
+func (m *PrometheusMetrics) UpdateNTPOffset(target string, seconds float64) {
+	m.ntpOffset.WithLabelValues(target).Set(seconds)
+}
+
+func (m *PrometheusMetrics) UpdateTargetDegraded(target string, degraded bool) {
+	if degraded {
+		m.targetDegraded.WithLabelValues(target).Set(1)
+	} else {
+		m.targetDegraded.WithLabelValues(target).Set(0)
+	}
+}
+
+func (m *PrometheusMetrics) UpdateGroupStatus(group string, up bool) {
+	if up {
+		m.groupUp.WithLabelValues(group).Set(1)
+	} else {
+		m.groupUp.WithLabelValues(group).Set(0)
+	}
+}
+
+func (m *PrometheusMetrics) RecordProbeFailure(group, probe, errorClass string) {
+	m.probeFailures.WithLabelValues(group, probe, errorClass).Inc()
+}
+
+func (m *PrometheusMetrics) ObserveProbeLatency(target, probe string, seconds float64) {
+	m.probeLatency.WithLabelValues(target, probe).Observe(seconds)
+}
+
+func (m *PrometheusMetrics) UpdateQueueDepth(depth int) {
+	m.queueDepth.Set(float64(depth))
+}
+
+func (m *PrometheusMetrics) ObserveQueueWait(seconds float64) {
+	m.queueWait.Observe(seconds)
+}
+
 func (m *PrometheusMetrics) UpdateCheckDuration(duration float64) {
 	m.checkDuration.Set(duration)
 }
@@ -94,4 +255,9 @@ func (m *PrometheusMetrics) UpdateCheckSummary(stats types.SummaryStats) {
 	m.checksFailed.WithLabelValues("external").Set(float64(stats.Failed))
 
 	m.checksTotal.WithLabelValues("corporate").Set(float64(stats.CorporateChecks))
+	m.checksTotal.WithLabelValues("global").Set(float64(stats.GlobalChecks))
+
+	for group, total := range stats.GroupChecks {
+		m.checksTotal.WithLabelValues(group).Set(float64(total))
+	}
 }
\ No newline at end of file
@@ -4,6 +4,9 @@ type SummaryStats struct {
     TotalChecks     int `json:"total_checks"`
     Successful      int `json:"successful"`
     Failed          int `json:"failed"`
+    Degraded        int `json:"degraded"`
     ExternalChecks  int `json:"external_checks"`
     CorporateChecks int `json:"corporate_checks"`
+    GlobalChecks    int `json:"global_checks"`
+    GroupChecks     map[string]int `json:"group_checks,omitempty"`
 }
\ No newline at end of file
@@ -1,31 +1,50 @@
 package utils
 
 import (
+	"context"
 	"time"
 )
 
-func Retry(attempts int, sleep time.Duration, fn func() bool) bool {
+// Retry calls fn up to attempts times, sleeping between failed attempts. It
+// gives up early when ctx is cancelled.
+func Retry(ctx context.Context, attempts int, sleep time.Duration, fn func() bool) bool {
 	for i := 0; i < attempts; i++ {
 		if fn() {
 			return true
 		}
-		if i < attempts-1 {
-			time.Sleep(sleep)
+		if i < attempts-1 && !sleepContext(ctx, sleep) {
+			return false
 		}
 	}
 	return false
 }
 
-func RetryWithBackoff(attempts int, initialSleep time.Duration, fn func() bool) bool {
+// RetryWithBackoff is like Retry but doubles the sleep after every attempt.
+func RetryWithBackoff(ctx context.Context, attempts int, initialSleep time.Duration, fn func() bool) bool {
 	sleep := initialSleep
 	for i := 0; i < attempts; i++ {
 		if fn() {
 			return true
 		}
 		if i < attempts-1 {
-			time.Sleep(sleep)
+			if !sleepContext(ctx, sleep) {
+				return false
+			}
 			sleep = sleep * 2 // Exponential backoff
 		}
 	}
 	return false
+}
+
+// sleepContext waits for d and reports whether ctx is still active.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
\ No newline at end of file